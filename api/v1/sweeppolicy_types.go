@@ -0,0 +1,81 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SweepPolicySpec picks a set of namespaces and says how they are swept.
+type SweepPolicySpec struct {
+	// Selector picks the namespaces the policy applies to by label. A namespace must match both
+	// the selector and NamePrefix; at least one of them must be set.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// NamePrefix picks the namespaces the policy applies to by name, e.g. "shop-pr-".
+	// +optional
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// TTL is how long after creation a namespace is deleted, e.g. "4h", "7d" or "2w". A namespace's
+	// own ttl annotation still wins over it.
+	// +kubebuilder:validation:MinLength=1
+	TTL string `json:"ttl"`
+
+	// HoldAnnotation is an extra annotation key that holds a namespace when set to "true", on top
+	// of preview-sweeper.maxsauce.com/hold.
+	// +optional
+	HoldAnnotation string `json:"holdAnnotation,omitempty"`
+
+	// IgnoreAnnotation is an extra annotation key that opts a namespace out of sweeping when set
+	// to "true", on top of preview-sweeper.maxsauce.com/ignore.
+	// +optional
+	IgnoreAnnotation string `json:"ignoreAnnotation,omitempty"`
+
+	// DryRun only logs the deletions of the policy's namespaces. A namespace's enforce annotation
+	// still wins over it.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// SweepPolicyStatus is the observed state of a SweepPolicy.
+type SweepPolicyStatus struct {
+	// ObservedGeneration is the generation the conditions were computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions holds the Valid condition: False means the sweeper ignores the policy.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Prefix",type=string,JSONPath=`.spec.namePrefix`
+// +kubebuilder:printcolumn:name="TTL",type=string,JSONPath=`.spec.ttl`
+// +kubebuilder:printcolumn:name="Dry Run",type=boolean,JSONPath=`.spec.dryRun`
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`
+
+// SweepPolicy is a set of sweep rules for the namespaces it selects, so one sweeper can serve
+// previews with different prefixes and TTLs.
+type SweepPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SweepPolicySpec   `json:"spec,omitempty"`
+	Status SweepPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SweepPolicyList contains a list of SweepPolicy.
+type SweepPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SweepPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SweepPolicy{}, &SweepPolicyList{})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// adminServer serves debugging endpoints that are too heavy or revealing for the metrics port.
+// It runs on every replica, not only the leader.
+type adminServer struct {
+	addr    string
+	handler http.Handler
+}
+
+func (a adminServer) Start(ctx context.Context) error {
+	srv := &http.Server{Addr: a.addr, Handler: a.handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	setupLog.Info("Serving admin endpoints", "address", a.addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (adminServer) NeedLeaderElection() bool { return false }
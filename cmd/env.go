@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// envPrefix is prepended to the upper-snake-cased flag name to get its env var,
+// e.g. --sweep-every -> PREVIEW_SWEEPER_SWEEP_EVERY.
+const envPrefix = "PREVIEW_SWEEPER_"
+
+// Value sources reported at startup
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceDefault = "default"
+)
+
+// legacyEnv keeps the pre-prefix env vars working. They lose to the prefixed ones.
+var legacyEnv = map[string]string{
+	"sweep-every":      "SWEEP_EVERY",
+	"ttl":              "TTL",
+	"dry-run":          "DRY_RUN",
+	"namespace-prefix": "NAMESPACE_PREFIX",
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv fills every flag that was not given on the command line from its env var.
+// Precedence is flag > PREVIEW_SWEEPER_* > legacy env > default.
+// Returns the source of each flag value, keyed by flag name.
+func applyEnv(fs *flag.FlagSet, lookup func(string) (string, bool)) (map[string]string, error) {
+	sources := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) { sources[f.Name] = sourceDefault })
+	fs.Visit(func(f *flag.Flag) { sources[f.Name] = sourceFlag })
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if sources[f.Name] == sourceFlag {
+			return
+		}
+		name := envName(f.Name)
+		val, ok := lookup(name)
+		if !ok || val == "" {
+			legacy, has := legacyEnv[f.Name]
+			if !has {
+				return
+			}
+			if val, ok = lookup(legacy); !ok || val == "" {
+				return
+			}
+			name = legacy
+		}
+		if err := fs.Set(f.Name, val); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", name, val, err))
+			return
+		}
+		sources[f.Name] = sourceEnv + ":" + name
+	})
+	return sources, errors.Join(errs...)
+}
+
+// secretFlag reports whether a flag's value may carry credentials, e.g. a token in a webhook
+// URL, so it must not be logged.
+func secretFlag(name string) bool {
+	return strings.HasSuffix(name, "-url")
+}
+
+// logResolvedOptions logs every flag's value and where it came from. Values of secretFlag
+// flags are only reported as set or not, like in the "Configuration parsed" log.
+func logResolvedOptions(logger logr.Logger, fs *flag.FlagSet, sources map[string]string) {
+	fs.VisitAll(func(f *flag.Flag) {
+		var value any = f.Value.String()
+		if secretFlag(f.Name) {
+			value = value != ""
+		}
+		logger.Info("Option resolved", "name", f.Name, "value", value, "source", sources[f.Name])
+	})
+}
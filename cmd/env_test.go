@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+func newTestFlagSet() (*flag.FlagSet, *time.Duration, *time.Duration, *bool) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sweepEvery := fs.Duration("sweep-every", defaultSweepEvery, "")
+	ttl := fs.Duration("ttl", defaultTTL, "")
+	dryRun := fs.Bool("dry-run", false, "")
+	return fs, sweepEvery, ttl, dryRun
+}
+
+func mapLookup(env map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+}
+
+func TestApplyEnvPrecedence(t *testing.T) {
+	fs, sweepEvery, ttl, dryRun := newTestFlagSet()
+	if err := fs.Parse([]string{"--ttl=5h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := applyEnv(fs, mapLookup(map[string]string{
+		"PREVIEW_SWEEPER_TTL":         "1h", // loses to the flag
+		"PREVIEW_SWEEPER_SWEEP_EVERY": "10m",
+		"SWEEP_EVERY":                 "20m", // loses to the prefixed var
+		"DRY_RUN":                     "true",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *ttl != 5*time.Hour || sources["ttl"] != sourceFlag {
+		t.Errorf("ttl = %s (%s), want 5h from flag", *ttl, sources["ttl"])
+	}
+	if *sweepEvery != 10*time.Minute || sources["sweep-every"] != "env:PREVIEW_SWEEPER_SWEEP_EVERY" {
+		t.Errorf("sweep-every = %s (%s), want 10m from prefixed env", *sweepEvery, sources["sweep-every"])
+	}
+	if !*dryRun || sources["dry-run"] != "env:DRY_RUN" {
+		t.Errorf("dry-run = %v (%s), want true from legacy env", *dryRun, sources["dry-run"])
+	}
+}
+
+func TestApplyEnvDefaultsAndErrors(t *testing.T) {
+	fs, sweepEvery, _, _ := newTestFlagSet()
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sources, err := applyEnv(fs, mapLookup(map[string]string{
+		"PREVIEW_SWEEPER_TTL":     "soon",
+		"PREVIEW_SWEEPER_DRY_RUN": "",
+	}))
+	if err == nil {
+		t.Fatal("expected an error for an unparseable env value")
+	}
+	if *sweepEvery != defaultSweepEvery || sources["sweep-every"] != sourceDefault {
+		t.Errorf("sweep-every = %s (%s), want default", *sweepEvery, sources["sweep-every"])
+	}
+	if sources["dry-run"] != sourceDefault {
+		t.Errorf("empty env var should be ignored, got source %s", sources["dry-run"])
+	}
+}
+
+func TestLogResolvedOptionsMasksURLs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("notify-url", "", "")
+	fs.String("sweep-report-url", "", "")
+	fs.String("external-liveness-url", "", "")
+	fs.Duration("ttl", defaultTTL, "")
+	const secret = "https://hooks.example.com/services/T0KEN"
+	if err := fs.Parse([]string{"--notify-url=" + secret, "--external-liveness-url=" + secret}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	logger := funcr.New(func(prefix, args string) { out.WriteString(args + "\n") }, funcr.Options{})
+	logResolvedOptions(logger, fs, map[string]string{})
+
+	if strings.Contains(out.String(), "hooks.example.com") {
+		t.Errorf("a URL reached the log:\n%s", out.String())
+	}
+	for _, want := range []string{
+		`"name"="notify-url" "value"=true`,
+		`"name"="sweep-report-url" "value"=false`,
+		`"name"="ttl" "value"="` + defaultTTL.String() + `"`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want %s in:\n%s", want, out.String())
+		}
+	}
+}
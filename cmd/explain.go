@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/seekin4u/preview-sweeper/internal/controller"
+)
+
+// runExplain implements `preview-sweeper explain <namespace>` and returns the exit code.
+// It talks to the apiserver directly, without a manager, cache or leader election.
+func runExplain(ctx context.Context, cfg *rest.Config, s *controller.NamespaceSweeper, name string) int {
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "Unable to create client")
+		return 1
+	}
+	s.Client = c
+	s.SweepPolicies = sweepPoliciesInstalled(c.RESTMapper())
+
+	e, err := s.Explain(ctx, name)
+	if err != nil {
+		setupLog.Error(err, "Unable to explain namespace", "namespace", name)
+		return 1
+	}
+	printExplanation(os.Stdout, s, e, time.Now().Add(s.ClockSkew))
+	return 0
+}
+
+// printExplanation writes e for humans. now is only used for relative times.
+func printExplanation(w io.Writer, s *controller.NamespaceSweeper, e controller.Explanation, now time.Time) {
+	ns := e.Namespace
+	created := ns.CreationTimestamp.Time
+	fmt.Fprintf(w, "Namespace:    %s\n", ns.Name)
+	fmt.Fprintf(w, "Created:      %s (%s ago)\n", created.UTC().Format(time.RFC3339), now.Sub(created).Round(time.Second))
+
+	if meta := relevantMetadata(s, ns.Labels, ns.Annotations); len(meta) > 0 {
+		fmt.Fprintln(w, "Considered:")
+		for _, m := range meta {
+			fmt.Fprintf(w, "  %s\n", m)
+		}
+	}
+
+	if e.Entry == nil {
+		fmt.Fprintf(w, "Disposition:  not listed, the namespace lacks the %s=true label\n", s.EnableLabel())
+		return
+	}
+	fmt.Fprintf(w, "Disposition:  %s\n", e.Entry.Disposition)
+	if e.Entry.Reason != "" {
+		fmt.Fprintf(w, "Reason:       %s\n", e.Entry.Reason)
+	}
+	if e.Entry.TTL != "" {
+		fmt.Fprintf(w, "TTL:          %s (%s)\n", e.Entry.TTL, e.Entry.TTLSource)
+	}
+	for i, step := range e.Entry.Steps {
+		label := ""
+		if i == 0 {
+			label = "Steps:"
+		}
+		fmt.Fprintf(w, "%-13s %s\n", label, step)
+	}
+	for i, p := range e.Entry.Problems {
+		label := ""
+		if i == 0 {
+			label = "Problems:"
+		}
+		fmt.Fprintf(w, "%-13s %s\n", label, p)
+	}
+
+	switch {
+	case e.Entry.ExpiresAt != nil:
+		fmt.Fprintf(w, "Expires:      %s (in %s), deleted by the first sweep after\n",
+			e.Entry.ExpiresAt.UTC().Format(time.RFC3339), e.Entry.ExpiresAt.Sub(now).Round(time.Second))
+	case e.Entry.Disposition == controller.DispositionWouldDelete:
+		fmt.Fprintln(w, "Expires:      now, deleted by the next sweep")
+	}
+	if e.Blocked != "" {
+		fmt.Fprintf(w, "Blocked:      no namespace is deleted right now: %s\n", e.Blocked)
+	}
+	if s.DryRun {
+		fmt.Fprintln(w, "Dry run:      --dry-run is set, deletions are only logged unless the namespace sets enforce=true")
+	}
+}
+
+// relevantMetadata lists the labels and annotations the sweeper's configuration looks at.
+func relevantMetadata(s *controller.NamespaceSweeper, labels, annotations map[string]string) []string {
+	keys := []string{s.EnableLabel(), s.ProtectAnnotation, s.GitOpsOwnershipKey}
+	keys = append(keys, s.RequireAnnotations...)
+	for _, m := range s.MatchAnnotations {
+		keys = append(keys, m.Key)
+	}
+	if s.ExcludeSelector != nil {
+		reqs, _ := s.ExcludeSelector.Requirements()
+		for _, r := range reqs {
+			keys = append(keys, r.Key())
+		}
+	}
+	relevant := func(k string) bool {
+		return strings.HasPrefix(k, "preview-sweeper.maxsauce.com/") || (k != "" && slices.Contains(keys, k))
+	}
+
+	var out []string
+	for k, v := range labels {
+		if relevant(k) {
+			out = append(out, fmt.Sprintf("label      %s=%s", k, v))
+		}
+	}
+	for k, v := range annotations {
+		if relevant(k) {
+			out = append(out, fmt.Sprintf("annotation %s=%s", k, v))
+		}
+	}
+	slices.Sort(out)
+	return out
+}
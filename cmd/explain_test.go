@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/seekin4u/preview-sweeper/internal/controller"
+)
+
+func TestPrintExplanation(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(30 * time.Minute)
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "preview-pr-7",
+		CreationTimestamp: metav1.NewTime(now.Add(-30 * time.Minute)),
+		Labels:            map[string]string{controller.LabelPreview: "true", "team": "web"},
+		Annotations: map[string]string{
+			controller.AnnotationTTL: "10m",
+			"example.com/protected":  "false",
+			"unrelated":              "x",
+		},
+	}}
+	s := &controller.NamespaceSweeper{ProtectAnnotation: "example.com/protected"}
+	e := controller.Explanation{Namespace: ns, Entry: &controller.AuditEntry{
+		Namespace:   ns.Name,
+		Disposition: controller.DispositionKept,
+		TTL:         "1h0m0s",
+		TTLSource:   "annotation",
+		ExpiresAt:   &expires,
+		Steps:       []string{"resolved TTL 10m0s from annotation", "raised to --min-ttl 1h0m0s"},
+	}}
+
+	var sb strings.Builder
+	printExplanation(&sb, s, e, now)
+	out := sb.String()
+	for _, want := range []string{
+		"Namespace:    preview-pr-7\n",
+		"Created:      2025-03-01T11:30:00Z (30m0s ago)\n",
+		"  annotation example.com/protected=false\n",
+		"  annotation preview-sweeper.maxsauce.com/ttl=10m\n",
+		"  label      preview-sweeper.maxsauce.com/enabled=true\n",
+		"Disposition:  kept\n",
+		"TTL:          1h0m0s (annotation)\n",
+		"Steps:        resolved TTL 10m0s from annotation\n              raised to --min-ttl 1h0m0s\n",
+		"Expires:      2025-03-01T12:30:00Z (in 30m0s)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"unrelated", "team", "Blocked"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output should not mention %q:\n%s", unwanted, out)
+		}
+	}
+
+	// Namespaces without the enable label are never listed
+	sb.Reset()
+	printExplanation(&sb, s, controller.Explanation{Namespace: ns}, now)
+	if !strings.Contains(sb.String(), "not listed") {
+		t.Errorf("want a not-listed disposition, got:\n%s", sb.String())
+	}
+}
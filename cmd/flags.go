@@ -0,0 +1,92 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// stringSlice is a repeatable flag. Each value may also be a comma-separated list,
+// which is how repeatable flags are passed through env vars.
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(val string) error {
+	for _, v := range strings.Split(val, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
+
+// rawStringSlice is a repeatable flag that keeps each value whole, for values such as
+// regexps that may contain commas. Through env vars it takes a single value.
+type rawStringSlice []string
+
+func (s *rawStringSlice) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *rawStringSlice) Set(val string) error {
+	*s = append(*s, val)
+	return nil
+}
+
+// parseOptionalTime parses an RFC3339 flag value; "" yields the zero time.
+func parseOptionalTime(val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, val)
+}
+
+// configMapCacheOptions limits the manager's ConfigMap informer to the given ConfigMaps, skipping
+// zero refs. A namespace holding a single one of them is narrowed down further by name.
+func configMapCacheOptions(refs ...types.NamespacedName) cache.Options {
+	names := map[string][]string{}
+	for _, ref := range refs {
+		if ref.Name != "" && !slices.Contains(names[ref.Namespace], ref.Name) {
+			names[ref.Namespace] = append(names[ref.Namespace], ref.Name)
+		}
+	}
+	if len(names) == 0 {
+		return cache.Options{}
+	}
+
+	namespaces := make(map[string]cache.Config, len(names))
+	for ns, n := range names {
+		var cfg cache.Config
+		if len(n) == 1 {
+			cfg.FieldSelector = fields.OneTermEqualSelector("metadata.name", n[0])
+		}
+		namespaces[ns] = cfg
+	}
+	return cache.Options{ByObject: map[client.Object]cache.ByObject{
+		&corev1.ConfigMap{}: {Namespaces: namespaces},
+	}}
+}
+
+// withLeaseCache limits the manager's Lease informer in opts to ref; a zero ref leaves opts alone.
+func withLeaseCache(opts cache.Options, ref types.NamespacedName) cache.Options {
+	if ref.Name == "" {
+		return opts
+	}
+	if opts.ByObject == nil {
+		opts.ByObject = map[client.Object]cache.ByObject{}
+	}
+	opts.ByObject[&coordinationv1.Lease{}] = cache.ByObject{Namespaces: map[string]cache.Config{
+		ref.Namespace: {FieldSelector: fields.OneTermEqualSelector("metadata.name", ref.Name)},
+	}}
+	return opts
+}
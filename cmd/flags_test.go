@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestConfigMapCacheOptions(t *testing.T) {
+	if opts := configMapCacheOptions(types.NamespacedName{}); opts.ByObject != nil {
+		t.Errorf("no refs should leave the cache alone, got %v", opts.ByObject)
+	}
+
+	opts := configMapCacheOptions(
+		types.NamespacedName{Namespace: "platform", Name: "holds"},
+		types.NamespacedName{Namespace: "platform", Name: "maintenance"},
+		types.NamespacedName{Namespace: "ops", Name: "maintenance"},
+	)
+	if len(opts.ByObject) != 1 {
+		t.Fatalf("want one ByObject entry, got %d", len(opts.ByObject))
+	}
+	for _, by := range opts.ByObject {
+		if len(by.Namespaces) != 2 {
+			t.Fatalf("want 2 namespaces, got %v", by.Namespaces)
+		}
+		if by.Namespaces["platform"].FieldSelector != nil {
+			t.Errorf("two ConfigMaps in one namespace can't share a name selector")
+		}
+		if sel := by.Namespaces["ops"].FieldSelector; sel == nil || sel.String() != "metadata.name=maintenance" {
+			t.Errorf("ops should be narrowed to its one ConfigMap, got %v", sel)
+		}
+	}
+}
+
+func TestWithLeaseCache(t *testing.T) {
+	if opts := withLeaseCache(configMapCacheOptions(), types.NamespacedName{}); opts.ByObject != nil {
+		t.Errorf("no lease should leave the cache alone, got %v", opts.ByObject)
+	}
+
+	opts := withLeaseCache(
+		configMapCacheOptions(types.NamespacedName{Namespace: "platform", Name: "holds"}),
+		types.NamespacedName{Namespace: "ops", Name: "sweeper-liveness"},
+	)
+	if len(opts.ByObject) != 2 {
+		t.Fatalf("want ConfigMap and Lease entries, got %d", len(opts.ByObject))
+	}
+	for obj, by := range opts.ByObject {
+		if _, ok := obj.(*coordinationv1.Lease); !ok {
+			continue
+		}
+		if sel := by.Namespaces["ops"].FieldSelector; sel == nil || sel.String() != "metadata.name=sweeper-liveness" {
+			t.Errorf("lease cache should be narrowed to the lease, got %v", sel)
+		}
+	}
+}
@@ -1,25 +1,35 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	sweeperv1 "github.com/seekin4u/preview-sweeper/api/v1"
 	"github.com/seekin4u/preview-sweeper/internal/controller"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
-	"strconv"
-
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -38,14 +48,17 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(sweeperv1.AddToScheme(scheme))
 }
 
 func main() {
 	var metricsAddr string
+	var metricsNamespace string
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
 	var enableLeaderElection bool
 	var probeAddr string
+	var adminAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
@@ -53,9 +66,82 @@ func main() {
 	var sweepEvery time.Duration
 	var ttl time.Duration
 	var dryRun bool
+	var auditOnly bool
+	var auditOutput string
+	var maxListErrors int
+	var requireAnnotations stringSlice
+	var blockOnPendingLB bool
+	var killSwitchFile string
+	var matchAnnotations rawStringSlice
+	var policies stringSlice
+	var createdBefore, createdAfter string
+	var pressureAware bool
+	var uncachedDelete bool
+	var protectAnnotation string
+	var listTimeout time.Duration
+	var sweepTimeout time.Duration
+	var cacheSyncTimeout time.Duration
+	var detectSystemNamespaces bool
+	var skipGitOpsOwned bool
+	var gitOpsOwnershipKey string
+	var systemLabelMarkers stringSlice
+	var systemNamespaces stringSlice
+	var systemNamespaceRegexps rawStringSlice
+	var deletePercent float64
+	var maxDeletesPerSweep int
+	var deleteConcurrency int
+	var deletionPropagation string
+	var staleFactor float64
+	var gracePeriod, maxGracePeriod time.Duration
+	var scaleToZero bool
+	var settlePeriod time.Duration
+	var confirmThreshold time.Duration
+	var deleteSpread time.Duration
+	var emptyTTLMeans string
+	var ageSource string
+	var deleteNowOverridesHold bool
+	var pressureNodeFraction float64
+	var livenessURL string
+	var preDeleteExec string
+	var preDeleteExecTimeout time.Duration
+	var preDeleteExecFailOpen bool
+	var notifyURL string
+	var sweepReportURL string
+	var sweepReportTimeout time.Duration
+	var eventMessageTemplate string
+	var notifyTimeout, notifyBreakerCooldown time.Duration
+	var notifyRetries, notifyBreakerThreshold int
+	var countdownWindow time.Duration
+	var shadowSelector string
+	var orderedDelete bool
+	var minTTL time.Duration
+	var minAge time.Duration
+	var idleTTL time.Duration
+	var metricLabelMaxLen int
+	var trackSweepCount bool
+	var stampOnDelete bool
+	var holdRegistry string
+	var sweepGuard string
+	var livenessLease string
+	var sweepGuardTTL time.Duration
+	var auditConfigMap string
+	var auditConfigMapMaxEntries int
+	var maintenanceConfigMap string
+	var maintenanceBonus time.Duration
+	var denyPrefixes stringSlice
+	var namespacePrefixes stringSlice
+	var labelKey string
+	var excludeSelector string
+	var checkClockSkew, correctClockSkew bool
+	var fieldManager string
+	var livenessTimeout, livenessCacheTTL time.Duration
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "Metrics bind address, use 0 to disable")
+	flag.StringVar(&metricsNamespace, "metrics-namespace", controller.DefaultMetricsNamespace,
+		"Prefix of every metric name, e.g. platform_preview_sweeper to tell instances apart in a shared Prometheus")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "Health probe bind address")
+	flag.StringVar(&adminAddr, "admin-bind-address", "0",
+		"Bind address for debugging endpoints such as /debug/decisions, use 0 to disable; keep it on loopback")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election")
 	flag.BoolVar(&secureMetrics, "metrics-secure", true, "Serve metrics securely via HTTPS")
 	flag.StringVar(&webhookCertPath, "webhook-cert-path", "", "Path to webhook cert directory")
@@ -69,28 +155,175 @@ func main() {
 	// Sweeper flags
 	flag.DurationVar(&sweepEvery, "sweep-every", defaultSweepEvery, "How often to sweep namespaces")
 	flag.DurationVar(&ttl, "ttl", defaultTTL, "Namespace TTL before deletion")
+	flag.DurationVar(&minTTL, "min-ttl", 0,
+		"Raise ttl annotations shorter than this to this value, 0 = no minimum")
+	flag.DurationVar(&idleTTL, "idle-ttl", 0,
+		"Also expire namespaces that have had no Pods for longer than this, 0 = disabled (needs pods list RBAC)")
+	flag.DurationVar(&minAge, "min-age", 0,
+		"Never delete a namespace younger than this, whatever its TTL or annotations, 0 = no floor")
+	flag.DurationVar(&deleteSpread, "delete-spread", 0,
+		"Spread each sweep's deletions over up to this long instead of deleting back to back, 0 = off")
+	flag.DurationVar(&confirmThreshold, "confirm-threshold", 0,
+		"Keep expired namespaces older than this until annotated confirm-delete=true, 0 = off")
+	flag.DurationVar(&settlePeriod, "settle-period", 0,
+		"Only delete namespaces whose metadata has not changed for this long, 0 = off")
+	flag.StringVar(&emptyTTLMeans, "empty-ttl-means", "default",
+		`How to read a blank ttl annotation: "default" applies --ttl, "never" keeps the namespace`)
+	flag.StringVar(&ageSource, "age-source", controller.AgeSourceCreation,
+		`What a namespace's age counts from: "creation", or "oldest-workload" for its oldest Pod or Deployment (falls back to creation)`)
+	flag.DurationVar(&gracePeriod, "grace-period", 0,
+		"Keep expired namespaces this long, with a DeletionPending warning event, before deleting them")
+	flag.DurationVar(&maxGracePeriod, "max-grace-period", 24*time.Hour,
+		"Cap on the per-namespace grace annotation, 0 = no cap")
+	flag.BoolVar(&scaleToZero, "scale-to-zero", false,
+		"Scale Deployments and StatefulSets to zero when a namespace enters its grace period, delete it after")
+	flag.Float64Var(&staleFactor, "stale-factor", 0,
+		"Multiply the TTL of namespaces nobody has updated since creation by this factor (0-1), 0 = off")
 	flag.BoolVar(&dryRun, "dry-run", false, "Log and emit events instead of deleting namespaces")
+	flag.BoolVar(&auditOnly, "audit-only", false,
+		"Write a JSON report of every namespace's disposition each sweep, without deleting, patching or emitting events")
+	flag.StringVar(&auditOutput, "audit-output", "-",
+		"Where --audit-only writes its report, - = stdout")
+	flag.StringVar(&fieldManager, "field-manager", controller.DefaultFieldManager,
+		"Field manager, and user agent, that every write to the apiserver is attributed to")
+	flag.BoolVar(&checkClockSkew, "check-clock-skew", false,
+		"Estimate the clock skew to the apiserver at startup, log it and export preview_sweeper_clock_skew_seconds")
+	flag.BoolVar(&correctClockSkew, "correct-clock-skew", false,
+		"Correct namespace ages for the clock skew found at startup (implies --check-clock-skew)")
+	flag.IntVar(&maxListErrors, "max-consecutive-list-errors", 0,
+		"Exit after this many sweeps in a row failed to list namespaces, 0 = never")
+	flag.StringVar(&killSwitchFile, "kill-switch-file", "",
+		"No namespaces are deleted while a file exists at this path, checked every sweep")
+	flag.Var(&matchAnnotations, "match-annotation",
+		"Only sweep namespaces whose annotation matches key=regexp (repeatable, all must match)")
+	flag.Var(&policies, "policy",
+		"Named TTL name=ttl that namespaces opt into with the policy annotation (repeatable)")
+	flag.StringVar(&createdAfter, "created-after", "", "Only sweep namespaces created after this RFC3339 time")
+	flag.StringVar(&createdBefore, "created-before", "", "Only sweep namespaces created before this RFC3339 time")
+	flag.BoolVar(&pressureAware, "pressure-aware", false,
+		"Pause deletions while nodes report MemoryPressure (needs nodes list RBAC)")
+	flag.Float64Var(&pressureNodeFraction, "pressure-node-fraction", 0.3,
+		"Fraction of nodes under MemoryPressure that pauses deletions with --pressure-aware")
+	flag.StringVar(&protectAnnotation, "protect-annotation", "",
+		"Annotation key that, set to \"true\", makes a namespace never deletable")
+	flag.BoolVar(&deleteNowOverridesHold, "delete-now-overrides-hold", false,
+		"Let the delete-now annotation win over hold")
+	flag.Float64Var(&deletePercent, "delete-percent-of-expired", 0,
+		"Delete only the oldest N% of expired namespaces per sweep, 0 = all")
+	flag.StringVar(&deletionPropagation, "deletion-propagation", string(metav1.DeletePropagationBackground),
+		"Propagation policy of namespace deletions: Background, Foreground or Orphan")
+	flag.IntVar(&maxDeletesPerSweep, "max-deletes-per-sweep", 0,
+		"Stop each sweep after deleting this many namespaces, deferring the rest, 0 = no cap")
+	flag.IntVar(&deleteConcurrency, "delete-concurrency", 1,
+		"How many expired namespaces to delete at once; can't be combined with --delete-spread")
+	flag.DurationVar(&cacheSyncTimeout, "cache-sync-timeout", 2*time.Minute,
+		"How long to wait for the namespace cache to sync before the first sweep")
+	flag.DurationVar(&listTimeout, "list-timeout", time.Minute,
+		"Abort a namespace List that takes longer than this, 0 = no timeout")
+	flag.DurationVar(&sweepTimeout, "sweep-timeout", 5*time.Minute,
+		"Abandon a sweep that runs longer than this so the next one gets scheduled, 0 = no timeout")
+	flag.BoolVar(&uncachedDelete, "uncached-delete", false,
+		"Re-read each namespace from the API server before deleting it (one extra GET per deletion)")
+	flag.BoolVar(&blockOnPendingLB, "block-on-pending-lb", false,
+		"Defer deletion while a LoadBalancer service in the namespace is pending (needs services list RBAC)")
+	flag.BoolVar(&detectSystemNamespaces, "detect-system-namespaces", false,
+		"Skip namespaces whose labels mark them as platform namespaces (OpenShift, addons, ...)")
+	flag.Var(&systemLabelMarkers, "system-label-marker",
+		"Label key or key=value marking a system namespace for --detect-system-namespaces (repeatable, replaces the defaults)")
+	flag.Var(&systemNamespaces, "system-namespace",
+		"Namespace name never swept, on top of kube-system, kube-public and default (repeatable)")
+	flag.Var(&systemNamespaceRegexps, "system-namespace-regexp",
+		"Regexp of namespace names never swept (repeatable)")
+	flag.BoolVar(&skipGitOpsOwned, "skip-gitops-owned", false,
+		"Skip namespaces owned by a GitOps tool unless annotated enforce=true")
+	flag.StringVar(&gitOpsOwnershipKey, "gitops-ownership-key", "argocd.argoproj.io/tracking-id",
+		"Label or annotation key marking GitOps ownership for --skip-gitops-owned")
+	flag.StringVar(&eventMessageTemplate, "event-message-template", "",
+		"Go template for deletion event messages (.Namespace, .Age, .TTL, .TTLSource, .DryRun, .Controller)")
+	flag.StringVar(&sweepReportURL, "sweep-report-url", "",
+		"Webhook that gets a JSON summary of every sweep, including the deleted namespaces, for reporting")
+	flag.DurationVar(&sweepReportTimeout, "sweep-report-timeout", 10*time.Second,
+		"Timeout of each --sweep-report-url POST")
+	flag.StringVar(&notifyURL, "notify-url", "",
+		"Webhook that gets a JSON POST just before every namespace deletion, and for every dry-run one")
+	flag.DurationVar(&notifyTimeout, "notify-timeout", 5*time.Second,
+		"Timeout of each --notify-url attempt")
+	flag.IntVar(&notifyRetries, "notify-retries", 2,
+		"Retries with exponential backoff after a --notify-url timeout or 5xx")
+	flag.IntVar(&notifyBreakerThreshold, "notify-breaker-threshold", 5,
+		"Stop notifying after this many notifications in a row failed, 0 = never")
+	flag.DurationVar(&notifyBreakerCooldown, "notify-breaker-cooldown", 5*time.Minute,
+		"How long notifications stay off once the breaker opened")
+	flag.StringVar(&livenessURL, "external-liveness-url", "",
+		"URL template (.Name, .Labels, .Annotations) answering {\"alive\": bool}; false deletes the namespace regardless of TTL")
+	flag.DurationVar(&livenessTimeout, "external-liveness-timeout", 5*time.Second,
+		"Timeout of each --external-liveness-url request; a timeout counts as alive")
+	flag.DurationVar(&livenessCacheTTL, "external-liveness-cache-ttl", time.Minute,
+		"How long --external-liveness-url answers are reused, 0 = ask every sweep")
+	flag.StringVar(&preDeleteExec, "pre-delete-exec", "",
+		"Executable run with the namespace name before each deletion; a non-zero exit defers the deletion")
+	flag.DurationVar(&preDeleteExecTimeout, "pre-delete-exec-timeout", 30*time.Second,
+		"Timeout of each --pre-delete-exec run")
+	flag.BoolVar(&preDeleteExecFailOpen, "pre-delete-exec-fail-open", false,
+		"Delete anyway when --pre-delete-exec times out or can't be run, instead of deferring")
+	flag.DurationVar(&countdownWindow, "countdown-window", 0,
+		"Export time_to_deletion_seconds per namespace expiring within this window, 0 = off")
+	flag.IntVar(&metricLabelMaxLen, "metric-label-max-length", 40,
+		"Shorten namespace names in metric labels to this length with a hash suffix, 0 = never")
+	flag.BoolVar(&trackSweepCount, "track-sweep-count", false,
+		"Keep a sweep-count annotation on every candidate (one patch per candidate per sweep)")
+	flag.BoolVar(&stampOnDelete, "stamp-on-delete", false,
+		"Label and annotate each namespace as deleted by the sweeper right before deleting it (one patch per deletion)")
+	flag.StringVar(&sweepGuard, "sweep-guard-configmap", "",
+		"namespace/name of a ConfigMap replicas stamp while sweeping, so others skip; a lighter alternative to --leader-elect")
+	flag.DurationVar(&sweepGuardTTL, "sweep-guard-ttl", 10*time.Minute,
+		"How long a --sweep-guard-configmap stamp blocks other replicas; must exceed the longest sweep")
+	flag.StringVar(&auditConfigMap, "audit-configmap", "",
+		"namespace/name of a ConfigMap that gets a JSON record of every deleted (or dry-run) namespace after each sweep; created if missing")
+	flag.IntVar(&auditConfigMapMaxEntries, "audit-configmap-max-entries", controller.DefaultDeletionLogMaxEntries,
+		"How many of the most recent records --audit-configmap keeps")
+	flag.StringVar(&livenessLease, "liveness-lease", "",
+		"namespace/name of a Lease renewed after every sweep that could list namespaces, for external monitoring of sweep progress")
+	flag.StringVar(&holdRegistry, "hold-registry-configmap", "",
+		"namespace/name of a ConfigMap whose keys are held namespace names and values the reasons")
+	flag.BoolVar(&orderedDelete, "ordered-delete", false,
+		"Delete expired namespaces by ascending delete-order annotation, unannotated ones last")
+	flag.StringVar(&shadowSelector, "shadow-selector", "",
+		"Namespace name regexp evaluated alongside --namespace-prefix; differences are only logged")
+	flag.StringVar(&maintenanceConfigMap, "maintenance-configmap", "",
+		"namespace/name of a ConfigMap; maintenance mode is on while its \"enabled\" key is \"true\"")
+	flag.DurationVar(&maintenanceBonus, "maintenance-bonus", 0,
+		"Added to every TTL while maintenance mode is on (see --maintenance-configmap)")
+	flag.StringVar(&labelKey, "label-key", controller.LabelPreview,
+		`Label that enables sweeping a namespace when "true"`)
+	flag.Var(&namespacePrefixes, "namespace-prefix",
+		`Only sweep namespaces whose name starts with this prefix (repeatable, default "preview-")`)
+	flag.Var(&denyPrefixes, "deny-prefix",
+		"Never delete namespaces whose name starts with this prefix, even if otherwise eligible (repeatable)")
+	flag.StringVar(&excludeSelector, "exclude-selector", "",
+		"Never delete namespaces whose labels match this label selector, e.g. protected=true")
+	flag.Var(&requireAnnotations, "require-annotation",
+		"Only sweep namespaces carrying this annotation key (repeatable, all must be present)")
 
 	opts := zap.Options{Development: true}
 	opts.BindFlags(flag.CommandLine)
-	flag.Parse()
-
-	// Allow overriding from environment variables (useful for tests)
-	if envVal := os.Getenv("SWEEP_EVERY"); envVal != "" {
-		if dur, err := time.ParseDuration(envVal); err == nil {
-			sweepEvery = dur
-		}
-	}
-	if envVal := os.Getenv("TTL"); envVal != "" {
-		if dur, err := time.ParseDuration(envVal); err == nil {
-			ttl = dur
-		}
-	}
-	if envVal := os.Getenv("DRY_RUN"); envVal != "" {
-		if dryrnenv, err := strconv.ParseBool(envVal); err == nil {
-			dryRun = dryrnenv
-		}
+	// `preview-sweeper explain [flags] <namespace>` prints how a sweep would treat one namespace
+	args := os.Args[1:]
+	explaining := len(args) > 0 && args[0] == "explain"
+	if explaining {
+		args = args[1:]
 	}
+	_ = flag.CommandLine.Parse(args)
+
+	// Every flag can also come from PREVIEW_SWEEPER_<FLAG_NAME> (flag > env > default)
+	sources, err := applyEnv(flag.CommandLine, os.LookupEnv)
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	// Collect every configuration problem and report them together before exiting
+	var v validator
+	v.add(err)
+	logResolvedOptions(setupLog, flag.CommandLine, sources)
 
 	// Sanity checks
 	if ttl <= 0 {
@@ -102,16 +335,269 @@ func main() {
 		sweepEvery = defaultSweepEvery
 	}
 
+	annotationMatchers := make([]controller.AnnotationMatcher, 0, len(matchAnnotations))
+	for _, raw := range matchAnnotations {
+		m, err := controller.ParseAnnotationMatcher(raw)
+		if err != nil {
+			v.add(fmt.Errorf("--match-annotation: %w", err))
+			continue
+		}
+		annotationMatchers = append(annotationMatchers, m)
+	}
+
+	policyTTLs := make(map[string]time.Duration, len(policies))
+	for _, raw := range policies {
+		name, ttl, err := controller.ParsePolicy(raw)
+		if err != nil {
+			v.add(fmt.Errorf("--policy: %w", err))
+			continue
+		}
+		policyTTLs[name] = ttl
+	}
+
+	systemConfig := controller.SystemNamespaceConfig{Names: systemNamespaces}
+	for _, raw := range systemNamespaceRegexps {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			v.add(fmt.Errorf("--system-namespace-regexp: %w", err))
+			continue
+		}
+		systemConfig.Patterns = append(systemConfig.Patterns, re)
+	}
+
+	createdAfterTime, err := parseOptionalTime(createdAfter)
+	v.add(wrapFlagErr("created-after", err))
+	createdBeforeTime, err := parseOptionalTime(createdBefore)
+	v.add(wrapFlagErr("created-before", err))
+	v.check(createdAfterTime.IsZero() || createdBeforeTime.IsZero() || createdAfterTime.Before(createdBeforeTime),
+		"--created-after (%s) must be before --created-before (%s)", createdAfter, createdBefore)
+
+	var liveness *controller.LivenessChecker
+	if livenessURL != "" {
+		tmpl, err := controller.ParseLivenessURL(livenessURL)
+		v.add(wrapFlagErr("external-liveness-url", err))
+		liveness = &controller.LivenessChecker{URL: tmpl, Timeout: livenessTimeout, CacheTTL: livenessCacheTTL}
+	}
+
+	var holdRegistryName types.NamespacedName
+	if holdRegistry != "" {
+		holdRegistryName, err = controller.ParseConfigMapRef(holdRegistry)
+		v.add(wrapFlagErr("hold-registry-configmap", err))
+	}
+
+	var sweepGuardName types.NamespacedName
+	if sweepGuard != "" {
+		sweepGuardName, err = controller.ParseConfigMapRef(sweepGuard)
+		v.add(wrapFlagErr("sweep-guard-configmap", err))
+		v.check(sweepGuardTTL > 0, "--sweep-guard-ttl must be positive")
+	}
+
+	var auditConfigMapName types.NamespacedName
+	if auditConfigMap != "" {
+		auditConfigMapName, err = controller.ParseConfigMapRef(auditConfigMap)
+		v.add(wrapFlagErr("audit-configmap", err))
+		v.check(auditConfigMapMaxEntries > 0, "--audit-configmap-max-entries must be positive")
+	}
+
+	var livenessLeaseName types.NamespacedName
+	if livenessLease != "" {
+		livenessLeaseName, err = controller.ParseLeaseRef(livenessLease)
+		v.add(wrapFlagErr("liveness-lease", err))
+	}
+
+	var shadowRegexp *regexp.Regexp
+	if shadowSelector != "" {
+		shadowRegexp, err = regexp.Compile(shadowSelector)
+		v.add(wrapFlagErr("shadow-selector", err))
+	}
+
+	var excludeSel labels.Selector
+	if excludeSelector != "" {
+		excludeSel, err = labels.Parse(excludeSelector)
+		v.add(wrapFlagErr("exclude-selector", err))
+		v.check(err != nil || !excludeSel.Empty(), "--exclude-selector %q matches every namespace", excludeSelector)
+	}
+
+	var maintenanceConfigMapName types.NamespacedName
+	if maintenanceConfigMap != "" {
+		maintenanceConfigMapName, err = controller.ParseConfigMapRef(maintenanceConfigMap)
+		v.add(wrapFlagErr("maintenance-configmap", err))
+	}
+	v.check(maintenanceBonus >= 0, "--maintenance-bonus must not be negative, got %s", maintenanceBonus)
+	v.check((maintenanceBonus > 0) == (maintenanceConfigMap != ""),
+		"--maintenance-bonus and --maintenance-configmap must be set together")
+
+	v.check(auditOnly || auditOutput == "-", "--audit-output requires --audit-only")
+	v.check(emptyTTLMeans == "default" || emptyTTLMeans == "never",
+		`--empty-ttl-means must be "default" or "never", got %q`, emptyTTLMeans)
+	v.check(ageSource == controller.AgeSourceCreation || ageSource == controller.AgeSourceOldestWorkload,
+		`--age-source must be "creation" or "oldest-workload", got %q`, ageSource)
+	v.check(deleteSpread >= 0, "--delete-spread must not be negative, got %s", deleteSpread)
+	v.check(deleteSpread < sweepEvery, "--delete-spread (%s) must be shorter than --sweep-every (%s)", deleteSpread, sweepEvery)
+	v.check(confirmThreshold >= 0, "--confirm-threshold must not be negative, got %s", confirmThreshold)
+	v.check(settlePeriod >= 0, "--settle-period must not be negative, got %s", settlePeriod)
+	v.check(gracePeriod >= 0, "--grace-period must not be negative, got %s", gracePeriod)
+	v.check(maxGracePeriod >= 0, "--max-grace-period must not be negative, got %s", maxGracePeriod)
+	v.check(staleFactor >= 0 && staleFactor < 1, "--stale-factor must be in [0, 1), got %v", staleFactor)
+	v.check(strings.TrimSpace(fieldManager) != "", "--field-manager must not be empty")
+
+	var eventTmpl *template.Template
+	if eventMessageTemplate != "" {
+		eventTmpl, err = controller.ParseEventMessageTemplate(eventMessageTemplate)
+		v.add(wrapFlagErr("event-message-template", err))
+	}
+
+	var notifier *controller.Notifier
+	if notifyURL != "" {
+		notifier = &controller.Notifier{
+			URL:              notifyURL,
+			Timeout:          notifyTimeout,
+			Retries:          notifyRetries,
+			BreakerThreshold: notifyBreakerThreshold,
+			BreakerCooldown:  notifyBreakerCooldown,
+		}
+	}
+	var reporter *controller.SweepReporter
+	if sweepReportURL != "" {
+		reporter = &controller.SweepReporter{URL: sweepReportURL, Timeout: sweepReportTimeout}
+	}
+	v.check(sweepReportTimeout >= 0, "--sweep-report-timeout must not be negative, got %s", sweepReportTimeout)
+	v.check(notifyTimeout >= 0, "--notify-timeout must not be negative, got %s", notifyTimeout)
+	v.check(notifyRetries >= 0, "--notify-retries must not be negative, got %d", notifyRetries)
+	v.check(notifyBreakerThreshold >= 0, "--notify-breaker-threshold must not be negative, got %d", notifyBreakerThreshold)
+	v.check(notifyBreakerCooldown >= 0, "--notify-breaker-cooldown must not be negative, got %s", notifyBreakerCooldown)
+
+	v.check(maxListErrors >= 0, "--max-consecutive-list-errors must not be negative, got %d", maxListErrors)
+	v.check(minTTL >= 0, "--min-ttl must not be negative, got %s", minTTL)
+	v.check(minAge >= 0, "--min-age must not be negative, got %s", minAge)
+	v.check(idleTTL >= 0, "--idle-ttl must not be negative, got %s", idleTTL)
+	v.check(listTimeout >= 0, "--list-timeout must not be negative, got %s", listTimeout)
+	v.check(sweepTimeout >= 0, "--sweep-timeout must not be negative, got %s", sweepTimeout)
+	v.check(sweepTimeout == 0 || sweepTimeout > deleteSpread,
+		"--sweep-timeout (%s) must be longer than --delete-spread (%s)", sweepTimeout, deleteSpread)
+	v.check(cacheSyncTimeout >= 0, "--cache-sync-timeout must not be negative, got %s", cacheSyncTimeout)
+	v.check(countdownWindow >= 0, "--countdown-window must not be negative, got %s", countdownWindow)
+	v.check(metricLabelMaxLen >= 0, "--metric-label-max-length must not be negative, got %d", metricLabelMaxLen)
+	v.check(livenessTimeout >= 0, "--external-liveness-timeout must not be negative, got %s", livenessTimeout)
+	var preDelete *controller.PreDeleteExec
+	if preDeleteExec != "" {
+		if _, err := exec.LookPath(preDeleteExec); err != nil {
+			v.add(wrapFlagErr("pre-delete-exec", err))
+		}
+		preDelete = &controller.PreDeleteExec{Path: preDeleteExec, Timeout: preDeleteExecTimeout, FailOpen: preDeleteExecFailOpen}
+	}
+	v.check(preDeleteExecTimeout > 0, "--pre-delete-exec-timeout must be positive, got %s", preDeleteExecTimeout)
+	v.check(livenessCacheTTL >= 0, "--external-liveness-cache-ttl must not be negative, got %s", livenessCacheTTL)
+	v.check(deletePercent >= 0 && deletePercent <= 100,
+		"--delete-percent-of-expired must be within [0, 100], got %g", deletePercent)
+	switch metav1.DeletionPropagation(deletionPropagation) {
+	case metav1.DeletePropagationBackground, metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan:
+	default:
+		v.add(fmt.Errorf("--deletion-propagation must be Background, Foreground or Orphan, got %q", deletionPropagation))
+	}
+	v.check(maxDeletesPerSweep >= 0, "--max-deletes-per-sweep must not be negative, got %d", maxDeletesPerSweep)
+	v.check(deleteConcurrency >= 1, "--delete-concurrency must be at least 1, got %d", deleteConcurrency)
+	v.check(deleteConcurrency == 1 || deleteSpread == 0, "--delete-concurrency and --delete-spread can't be combined")
+	v.check(pressureNodeFraction > 0 && pressureNodeFraction <= 1,
+		"--pressure-node-fraction must be within (0, 1], got %g", pressureNodeFraction)
+	v.check(!skipGitOpsOwned || gitOpsOwnershipKey != "", "--skip-gitops-owned needs a --gitops-ownership-key")
+	v.add(wrapFlagErr("metrics-namespace", controller.ValidateMetricsNamespace(metricsNamespace)))
+	v.add(wrapFlagErr("label-key", controller.ValidateLabelKey(labelKey)))
+
+	if explaining {
+		v.check(flag.NArg() == 1, "usage: %s explain [flags] <namespace>", filepath.Base(os.Args[0]))
+	}
+
+	if err := v.err(); err != nil {
+		setupLog.Error(err, "Invalid configuration")
+		os.Exit(1)
+	}
+
 	setupLog.Info("Configuration parsed",
 		"SweepEvery", sweepEvery,
 		"TTL", ttl,
 		"MetricsAddr", metricsAddr,
+		"MetricsNamespace", metricsNamespace,
+		"AdminAddr", adminAddr,
 		"LeaderElect", enableLeaderElection,
 		"DryRun", dryRun,
+		"FieldManager", fieldManager,
+		"CheckClockSkew", checkClockSkew,
+		"CorrectClockSkew", correctClockSkew,
+		"AuditOnly", auditOnly,
+		"AuditOutput", auditOutput,
+		"MinTTL", minTTL,
+		"MinAge", minAge,
+		"IdleTTL", idleTTL,
+		"StaleFactor", staleFactor,
+		"EmptyTTLMeans", emptyTTLMeans,
+		"AgeSource", ageSource,
+		"GracePeriod", gracePeriod,
+		"MaxGracePeriod", maxGracePeriod,
+		"ScaleToZero", scaleToZero,
+		"SettlePeriod", settlePeriod,
+		"ConfirmThreshold", confirmThreshold,
+		"DeleteSpread", deleteSpread,
+		"MaxConsecutiveListErrors", maxListErrors,
+		"RequireAnnotations", requireAnnotations,
+		"BlockOnPendingLB", blockOnPendingLB,
+		"KillSwitchFile", killSwitchFile,
+		"MatchAnnotations", matchAnnotations,
+		"Policies", policies,
+		"CreatedAfter", createdAfter,
+		"CreatedBefore", createdBefore,
+		"PressureAware", pressureAware,
+		"PressureNodeFraction", pressureNodeFraction,
+		"UncachedDelete", uncachedDelete,
+		"ProtectAnnotation", protectAnnotation,
+		"ListTimeout", listTimeout,
+		"SweepTimeout", sweepTimeout,
+		"CacheSyncTimeout", cacheSyncTimeout,
+		"DetectSystemNamespaces", detectSystemNamespaces,
+		"SystemLabelMarkers", systemLabelMarkers,
+		"SystemNamespaces", systemNamespaces,
+		"SystemNamespaceRegexps", systemNamespaceRegexps,
+		"SkipGitOpsOwned", skipGitOpsOwned,
+		"GitOpsOwnershipKey", gitOpsOwnershipKey,
+		"DeletePercentOfExpired", deletePercent,
+		"MaxDeletesPerSweep", maxDeletesPerSweep,
+		"DeleteConcurrency", deleteConcurrency,
+		"DeletionPropagation", deletionPropagation,
+		"DeleteNowOverridesHold", deleteNowOverridesHold,
+		"CountdownWindow", countdownWindow,
+		"MetricLabelMaxLen", metricLabelMaxLen,
+		"TrackSweepCount", trackSweepCount,
+		"StampOnDelete", stampOnDelete,
+		"LabelKey", labelKey,
+		"NamespacePrefixes", namespacePrefixes,
+		"DenyPrefixes", denyPrefixes,
+		"ExcludeSelector", excludeSelector,
+		"HoldRegistryConfigMap", holdRegistry,
+		"SweepGuardConfigMap", sweepGuard,
+		"SweepGuardTTL", sweepGuardTTL,
+		"AuditConfigMap", auditConfigMap,
+		"AuditConfigMapMaxEntries", auditConfigMapMaxEntries,
+		"LivenessLease", livenessLease,
+		"MaintenanceConfigMap", maintenanceConfigMap,
+		"MaintenanceBonus", maintenanceBonus,
+		"ShadowSelector", shadowSelector,
+		"OrderedDelete", orderedDelete,
+		"EventMessageTemplate", eventMessageTemplate,
+		"NotifyURL", notifyURL != "",
+		"SweepReportURL", sweepReportURL != "",
+		"SweepReportTimeout", sweepReportTimeout,
+		"NotifyTimeout", notifyTimeout,
+		"NotifyRetries", notifyRetries,
+		"NotifyBreakerThreshold", notifyBreakerThreshold,
+		"NotifyBreakerCooldown", notifyBreakerCooldown,
+		"ExternalLivenessURL", livenessURL != "",
+		"ExternalLivenessTimeout", livenessTimeout,
+		"ExternalLivenessCacheTTL", livenessCacheTTL,
+		"PreDeleteExec", preDeleteExec,
+		"PreDeleteExecTimeout", preDeleteExecTimeout,
+		"PreDeleteExecFailOpen", preDeleteExecFailOpen,
 	)
 
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
-
 	// HTTP/2 disable for security unless explicitly enabled
 	if !enableHTTP2 {
 		tlsOpts = append(tlsOpts, func(c *tls.Config) {
@@ -122,7 +608,6 @@ func main() {
 
 	// Cert watchers
 	var metricsCertWatcher, webhookCertWatcher *certwatcher.CertWatcher
-	var err error
 
 	if len(webhookCertPath) > 0 {
 		webhookCertWatcher, err = certwatcher.New(
@@ -155,11 +640,22 @@ func main() {
 	webhookServer := webhook.NewServer(webhook.Options{TLSOpts: webhookTLSOpts})
 
 	// Metrics server setup
+	if err := controller.SetMetricsNamespace(metricsNamespace); err != nil {
+		setupLog.Error(err, "Failed to register metrics")
+		os.Exit(1)
+	}
 	metricsServerOptions := metricsserver.Options{
 		BindAddress:   metricsAddr,
 		SecureServing: secureMetrics,
 		TLSOpts:       tlsOpts,
 	}
+	// Same registry in OpenMetrics format, which is the only one that carries exemplars
+	metricsServerOptions.ExtraHandlers = map[string]http.Handler{
+		"/openmetrics": promhttp.HandlerFor(crmetrics.Registry, promhttp.HandlerOpts{
+			ErrorHandling:     promhttp.HTTPErrorOnError,
+			EnableOpenMetrics: true,
+		}),
+	}
 	if secureMetrics {
 		metricsServerOptions.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
@@ -169,9 +665,130 @@ func main() {
 		})
 	}
 
+	restConfig := ctrl.GetConfigOrDie()
+	// Deletes have no field manager, the user agent attributes them in audit logs
+	restConfig.UserAgent = fieldManager
+
+	ctx := ctrl.SetupSignalHandler()
+
+	// Identifies which replica performed a deletion; POD_NAME comes from the downward API
+	tracer, shutdownTracing, err := setupTracing(ctx)
+	if err != nil {
+		setupLog.Error(err, "Unable to set up tracing")
+		os.Exit(1)
+	}
+
+	controllerID := os.Getenv("POD_NAME")
+	if controllerID == "" {
+		controllerID, _ = os.Hostname()
+	}
+
+	sweeper := &controller.NamespaceSweeper{
+		TTL:          ttl,
+		DryRun:       dryRun,
+		ControllerID: controllerID,
+		FieldManager: fieldManager,
+		Tracer:       tracer,
+
+		MinTTL:                   minTTL,
+		MinAge:                   minAge,
+		IdleTTL:                  idleTTL,
+		StaleFactor:              staleFactor,
+		EmptyTTLNever:            emptyTTLMeans == "never",
+		AgeFromOldestWorkload:    ageSource == controller.AgeSourceOldestWorkload,
+		GracePeriod:              gracePeriod,
+		MaxGracePeriod:           maxGracePeriod,
+		ScaleToZero:              scaleToZero,
+		SettlePeriod:             settlePeriod,
+		ConfirmThreshold:         confirmThreshold,
+		DeleteSpread:             deleteSpread,
+		MaxConsecutiveListErrors: maxListErrors,
+		RequireAnnotations:       requireAnnotations,
+		BlockOnPendingLB:         blockOnPendingLB,
+		KillSwitchFile:           killSwitchFile,
+		MatchAnnotations:         annotationMatchers,
+		Policies:                 policyTTLs,
+		CreatedAfter:             createdAfterTime,
+		CreatedBefore:            createdBeforeTime,
+		PressureAware:            pressureAware,
+		PressureNodeFraction:     pressureNodeFraction,
+		ProtectAnnotation:        protectAnnotation,
+		ListTimeout:              listTimeout,
+		SweepTimeout:             sweepTimeout,
+		CacheSyncTimeout:         cacheSyncTimeout,
+		DetectSystemNamespaces:   detectSystemNamespaces,
+		SystemLabelMarkers:       systemLabelMarkers,
+		SystemNamespaces:         systemConfig,
+		SkipGitOpsOwned:          skipGitOpsOwned,
+		GitOpsOwnershipKey:       gitOpsOwnershipKey,
+		DeletePercentOfExpired:   deletePercent,
+		MaxDeletesPerSweep:       maxDeletesPerSweep,
+		Concurrency:              deleteConcurrency,
+		PropagationPolicy:        metav1.DeletionPropagation(deletionPropagation),
+		DeleteNowOverridesHold:   deleteNowOverridesHold,
+		CountdownWindow:          countdownWindow,
+		MetricLabelMaxLen:        metricLabelMaxLen,
+		TrackSweepCount:          trackSweepCount,
+		StampOnDelete:            stampOnDelete,
+		LabelKey:                 labelKey,
+		Prefixes:                 namespacePrefixes,
+		DenyPrefixes:             denyPrefixes,
+		ExcludeSelector:          excludeSel,
+		HoldRegistry:             holdRegistryName,
+		SweepGuard:               sweepGuardName,
+		SweepGuardTTL:            sweepGuardTTL,
+		DeletionLog:              auditConfigMapName,
+		DeletionLogMaxEntries:    auditConfigMapMaxEntries,
+		LivenessLease:            livenessLeaseName,
+		MaintenanceConfigMap:     maintenanceConfigMapName,
+		MaintenanceBonus:         maintenanceBonus,
+		ShadowSelector:           shadowRegexp,
+		OrderedDelete:            orderedDelete,
+		AuditOnly:                auditOnly,
+		AuditOutput:              auditOutput,
+		EventMessageTemplate:     eventTmpl,
+		Notifier:                 notifier,
+		Reporter:                 reporter,
+		Liveness:                 liveness,
+		PreDelete:                preDelete,
+	}
+	scheduleSweeper(sweeper, sweepEvery)
+
+	if checkClockSkew || correctClockSkew {
+		skewCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		skew, err := controller.EstimateClockSkew(skewCtx, restConfig)
+		cancel()
+		switch {
+		case err != nil:
+			setupLog.Error(err, "Unable to estimate clock skew to the apiserver, namespace ages are not corrected")
+		case skew.Abs() < time.Second:
+			// Within the resolution of the apiserver's Date header
+			setupLog.Info("Clock skew to the apiserver is negligible", "skew", skew.String())
+		default:
+			setupLog.Info("Controller clock is skewed against the apiserver", "skew", skew.String(),
+				"corrected", correctClockSkew)
+			if correctClockSkew {
+				sweeper.ClockSkew = skew
+			}
+		}
+	}
+
+	if explaining {
+		os.Exit(runExplain(ctx, restConfig, sweeper, flag.Arg(0)))
+	}
+
 	// Manager
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// Only cache the ConfigMaps the sweeper reads, not every ConfigMap in the cluster
+	cacheOpts := configMapCacheOptions(holdRegistryName, maintenanceConfigMapName, sweepGuardName, auditConfigMapName)
+	// Nor every Lease, e.g. those of leader election
+	cacheOpts = withLeaseCache(cacheOpts, livenessLeaseName)
+	// The initial sync says nothing about informers that lose the apiserver later
+	watchHealth := &controller.WatchHealth{}
+	cacheOpts.DefaultWatchErrorHandler = watchHealth.Handler
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cacheOpts,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -183,16 +800,33 @@ func main() {
 		os.Exit(1)
 	}
 
-	ctx := ctrl.SetupSignalHandler()
-
-	rec := mgr.GetEventRecorderFor("preview-sweeper")
-	sweeper := &controller.NamespaceSweeper{
-		Client:        mgr.GetClient(),
-		TTL:           ttl,
-		Recorder:      rec,
-		Interval:      sweepEvery,
-		JitterPercent: 0.05,
-		DryRun:        dryRun,
+	sweeper.Client = mgr.GetClient()
+	sweeper.Recorder = mgr.GetEventRecorderFor("preview-sweeper")
+	sweeper.CacheHealthy = func(ctx context.Context) bool {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return mgr.GetCache().WaitForCacheSync(ctx) && watchHealth.Healthy(time.Now())
+	}
+	if uncachedDelete {
+		sweeper.APIReader = mgr.GetAPIReader()
+	}
+	if sweepPoliciesInstalled(mgr.GetRESTMapper()) {
+		sweeper.SweepPolicies = true
+		if err := (&controller.SweepPolicyReconciler{Client: mgr.GetClient(), FieldManager: fieldManager}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to set up SweepPolicy controller")
+			os.Exit(1)
+		}
+	}
+	if enableLeaderElection {
+		elected := mgr.Elected()
+		sweeper.IsLeader = func() bool {
+			select {
+			case <-elected:
+				return true
+			default:
+				return false
+			}
+		}
 	}
 
 	// letting manager to lifecycle
@@ -201,6 +835,18 @@ func main() {
 		os.Exit(1)
 	}
 
+	if adminAddr != "0" && adminAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/decisions", sweeper.DecisionsHandler())
+		mux.Handle("/debug/config", sweeper.ConfigHandler())
+		mux.Handle("/holds", sweeper.HoldsHandler())
+		mux.Handle("/sweep", sweeper.TriggerHandler())
+		if err := mgr.Add(adminServer{addr: adminAddr, handler: mux}); err != nil {
+			setupLog.Error(err, "Unable to add admin server")
+			os.Exit(1)
+		}
+	}
+
 	if metricsCertWatcher != nil {
 		if err := mgr.Add(metricsCertWatcher); err != nil {
 			setupLog.Error(err, "Unable to add metrics cert watcher")
@@ -212,13 +858,18 @@ func main() {
 			setupLog.Error(err, "Unable to add webhook cert watcher")
 			os.Exit(1)
 		}
+		// Only with certs: a cluster without the webhook configuration never calls it
+		if err := controller.SetupTTLWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to set up ttl annotation webhook")
+			os.Exit(1)
+		}
 	}
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "Unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", sweeper.ReadyCheck); err != nil {
 		setupLog.Error(err, "Unable to set up ready check")
 		os.Exit(1)
 	}
@@ -231,4 +882,10 @@ func main() {
 		setupLog.Error(err, "Problem running manager")
 		os.Exit(1)
 	}
+	// Flush the spans of the last sweep
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		setupLog.Error(err, "Failed to flush traces")
+	}
 }
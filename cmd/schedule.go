@@ -0,0 +1,18 @@
+package main
+
+import (
+	"time"
+
+	"github.com/seekin4u/preview-sweeper/internal/controller"
+)
+
+// sweepJitterPercent spreads sweeps by +-2.5% of --sweep-every, so replicas restarted together
+// don't hit the apiserver in lockstep.
+const sweepJitterPercent = 0.05
+
+// scheduleSweeper makes s sweep every --sweep-every. Start would fall back to a daily sweep on
+// an unset Interval, silently dropping the flag.
+func scheduleSweeper(s *controller.NamespaceSweeper, every time.Duration) {
+	s.Interval = every
+	s.JitterPercent = sweepJitterPercent
+}
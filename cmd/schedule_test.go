@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seekin4u/preview-sweeper/internal/controller"
+)
+
+func TestScheduleSweeper(t *testing.T) {
+	s := &controller.NamespaceSweeper{TTL: time.Hour}
+	scheduleSweeper(s, 10*time.Minute)
+	if s.Interval != 10*time.Minute {
+		t.Errorf("Interval = %s, want the --sweep-every value 10m", s.Interval)
+	}
+	if s.JitterPercent != sweepJitterPercent {
+		t.Errorf("JitterPercent = %g, want %g", s.JitterPercent, sweepJitterPercent)
+	}
+	if s.TTL != time.Hour {
+		t.Errorf("TTL changed to %s", s.TTL)
+	}
+}
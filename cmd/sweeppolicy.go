@@ -0,0 +1,24 @@
+package main
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	sweeperv1 "github.com/seekin4u/preview-sweeper/api/v1"
+)
+
+// sweepPoliciesInstalled reports whether the SweepPolicy CRD is installed. Without it the sweeper
+// runs on its flags alone.
+func sweepPoliciesInstalled(mapper meta.RESTMapper) bool {
+	gk := sweeperv1.GroupVersion.WithKind("SweepPolicy").GroupKind()
+	_, err := mapper.RESTMapping(gk, sweeperv1.GroupVersion.Version)
+	switch {
+	case err == nil:
+		setupLog.Info("SweepPolicy CRD installed, policies replace the flag selection and TTL while any exist")
+		return true
+	case meta.IsNoMatchError(err):
+		setupLog.Info("SweepPolicy CRD not installed, using the flag configuration")
+	default:
+		setupLog.Error(err, "Unable to look up the SweepPolicy CRD, using the flag configuration")
+	}
+	return false
+}
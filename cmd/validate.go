@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// validator collects every configuration problem so they can be reported in one go,
+// instead of making operators fix flags one restart at a time.
+type validator struct {
+	errs []error
+}
+
+// add records err, if any.
+func (v *validator) add(err error) {
+	if err != nil {
+		v.errs = append(v.errs, err)
+	}
+}
+
+// check records a problem described by format/args unless ok.
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf(format, args...))
+	}
+}
+
+// err returns all recorded problems joined, or nil.
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
+
+// wrapFlagErr prefixes err, if any, with the flag it came from.
+func wrapFlagErr(name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("--%s: %w", name, err)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatorAggregatesProblems(t *testing.T) {
+	var v validator
+	v.check(true, "never reported")
+	v.add(nil)
+	if err := v.err(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	v.add(wrapFlagErr("created-after", errors.New("bad time")))
+	v.check(false, "--list-timeout must not be negative, got %s", "-1s")
+	err := v.err()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"--created-after: bad time", "--list-timeout must not be negative"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
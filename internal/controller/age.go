@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Age sources, as reported by ageStart.
+const (
+	AgeSourceCreation       = "creation"
+	AgeSourceOldestWorkload = "oldest-workload"
+)
+
+// ageStart is when ns's age counts from, and why. That is its creation, unless
+// AgeFromOldestWorkload is set and it has Pods or Deployments not being deleted: then it is the
+// creation of the oldest of them, so the TTL runs from when the environment first came up rather
+// than from when the namespace was provisioned.
+func (s *NamespaceSweeper) ageStart(ctx context.Context, ns *corev1.Namespace) (time.Time, string, error) {
+	if !s.AgeFromOldestWorkload {
+		return ns.CreationTimestamp.Time, AgeSourceCreation, nil
+	}
+
+	var oldest time.Time
+	consider := func(obj client.Object) {
+		created := obj.GetCreationTimestamp().Time
+		if obj.GetDeletionTimestamp() == nil && !created.IsZero() && (oldest.IsZero() || created.Before(oldest)) {
+			oldest = created
+		}
+	}
+	var pods corev1.PodList
+	if err := s.Client.List(ctx, &pods, client.InNamespace(ns.Name)); err != nil {
+		return time.Time{}, "", err
+	}
+	for i := range pods.Items {
+		consider(&pods.Items[i])
+	}
+	var deployments appsv1.DeploymentList
+	if err := s.Client.List(ctx, &deployments, client.InNamespace(ns.Name)); err != nil {
+		return time.Time{}, "", err
+	}
+	for i := range deployments.Items {
+		consider(&deployments.Items[i])
+	}
+
+	if oldest.IsZero() {
+		return ns.CreationTimestamp.Time, AgeSourceCreation, nil
+	}
+	return oldest, AgeSourceOldestWorkload, nil
+}
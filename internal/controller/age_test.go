@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAgeFromOldestWorkload(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	meta := func(ns, name string, age time.Duration) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: ns, Name: name, CreationTimestamp: metav1.NewTime(now.Add(-age))}
+	}
+
+	leaving := meta("preview-mixed", "leaving", 4*time.Hour)
+	leaving.DeletionTimestamp = &metav1.Time{Time: now}
+	leaving.Finalizers = []string{"example.com/block"}
+	c := newFakeClient(nil,
+		previewNS("preview-mixed", 5*time.Hour, nil),
+		previewNS("preview-recent", 5*time.Hour, nil),
+		previewNS("preview-empty", 5*time.Hour, nil),
+		previewNS("preview-old", 5*time.Hour, nil),
+		&corev1.Pod{ObjectMeta: meta("preview-mixed", "web-1", 20*time.Minute)},
+		&corev1.Pod{ObjectMeta: meta("preview-mixed", "web-2", 40*time.Minute)},
+		&corev1.Pod{ObjectMeta: leaving},
+		&appsv1.Deployment{ObjectMeta: meta("preview-mixed", "web", 50*time.Minute)},
+		&appsv1.Deployment{ObjectMeta: meta("preview-recent", "web", 30*time.Minute)},
+		&corev1.Pod{ObjectMeta: meta("preview-old", "web-1", 2*time.Hour)},
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, AgeFromOldestWorkload: true}
+
+	// The oldest surviving workload wins, whatever its kind; terminating ones don't count
+	var ns corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-mixed"}, &ns)).To(Succeed())
+	start, src, err := s.ageStart(ctx, &ns)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(src).To(Equal(AgeSourceOldestWorkload))
+	g.Expect(start).To(BeTemporally("==", now.Add(-50*time.Minute)))
+
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-empty"}, &ns)).To(Succeed())
+	start, src, err = s.ageStart(ctx, &ns)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(src).To(Equal(AgeSourceCreation))
+	g.Expect(start).To(BeTemporally("==", ns.CreationTimestamp.Time))
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-mixed")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-recent")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-empty")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+}
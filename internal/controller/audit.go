@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Disposition is what a sweep decided, or in audit mode would decide, for a namespace.
+type Disposition string
+
+const (
+	// DispositionExcluded namespaces are not candidates: terminating, system, wrong prefix, filtered out.
+	DispositionExcluded Disposition = "excluded"
+	// DispositionProtected namespaces are candidates that can never be deleted.
+	DispositionProtected Disposition = "protected"
+	// DispositionHeld namespaces are held by annotation or the hold registry.
+	DispositionHeld Disposition = "held"
+	// DispositionKept namespaces have not reached their TTL yet.
+	DispositionKept Disposition = "kept"
+	// DispositionWouldDelete namespaces are expired or otherwise due for deletion.
+	DispositionWouldDelete Disposition = "would-delete"
+	// DispositionMisconfigured namespaces are never deleted because of a bad configuration.
+	DispositionMisconfigured Disposition = "misconfigured"
+)
+
+// AuditEntry is one namespace in an audit report.
+type AuditEntry struct {
+	Namespace   string      `json:"namespace"`
+	Disposition Disposition `json:"disposition"`
+	Reason      string      `json:"reason,omitempty"`
+	Age         string      `json:"age,omitempty"`
+	TTL         string      `json:"ttl,omitempty"`
+	TTLSource   string      `json:"ttlSource,omitempty"`
+	// ExpiresAt is when a kept namespace's TTL and grace period run out; the first sweep after deletes it.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Steps traces how the TTL was arrived at, e.g. a --min-ttl clamp or a maintenance bonus.
+	Steps []string `json:"steps,omitempty"`
+	// Problems lists misconfigurations that don't change the disposition, e.g. an unparseable TTL.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// AuditReport is written by an --audit-only sweep.
+type AuditReport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Blocked is why no namespace would be deleted this sweep, e.g. the kill switch; empty if none.
+	Blocked    string       `json:"blocked,omitempty"`
+	Namespaces []AuditEntry `json:"namespaces"`
+}
+
+// writeAuditReport writes r as JSON to path, "-" meaning stdout. Files are replaced atomically,
+// so readers never see a half-written report.
+func writeAuditReport(path string, stdout io.Writer, r AuditReport) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding audit report: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "-" || path == "" {
+		_, err = stdout.Write(data)
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".audit-*.json")
+	if err != nil {
+		return fmt.Errorf("writing audit report: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing audit report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing audit report: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing audit report: %w", err)
+	}
+	return nil
+}
+
+// ttlProblems reports a ttl annotation that resolveTTL had to ignore.
+func ttlProblems(annotations map[string]string, ttlSource string) []string {
+	raw, ok := annotations[AnnotationTTL]
+	if !ok || ttlSource == "annotation" || strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("unparseable %s annotation %q, the %s TTL applies", AnnotationTTL, raw, ttlSource)}
+}
+
+// graceProblem reports a grace annotation that resolveGrace had to ignore.
+func graceProblem(annotations map[string]string, graceSource string) (string, bool) {
+	raw, ok := annotations[AnnotationGrace]
+	if !ok || graceSource == "annotation" || strings.TrimSpace(raw) == "" {
+		return "", false
+	}
+	return fmt.Sprintf("invalid %s annotation %q, the %s grace period applies", AnnotationGrace, raw, graceSource), true
+}
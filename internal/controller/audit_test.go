@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestAuditOnlyReportsWithoutActing(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-expired", 3*time.Hour, nil),
+		previewNS("preview-held", 3*time.Hour, map[string]string{AnnotationHold: "true"}),
+		previewNS("preview-young", 10*time.Minute, nil),
+		previewNS("preview-bad-ttl", 3*time.Hour, map[string]string{AnnotationTTL: "soon"}),
+		previewNS("team-a", 3*time.Hour, nil),
+	)
+	rec := record.NewFakeRecorder(10)
+	out := filepath.Join(t.TempDir(), "audit.json")
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, AuditOnly: true, AuditOutput: out, TrackSweepCount: true}
+
+	res := s.SweepOnce(ctx)
+	g.Expect(res.Expired).To(Equal(2))
+	g.Expect(res.Deleted).To(BeZero())
+	for _, name := range []string{"preview-expired", "preview-bad-ttl"} {
+		g.Expect(isDeleted(ctx, c, name)).To(BeFalse(), name)
+	}
+	g.Expect(rec.Events).To(BeEmpty(), "audit mode must not emit events")
+
+	data, err := os.ReadFile(out)
+	g.Expect(err).NotTo(HaveOccurred())
+	var report AuditReport
+	g.Expect(json.Unmarshal(data, &report)).To(Succeed())
+
+	got := map[string]AuditEntry{}
+	for _, e := range report.Namespaces {
+		got[e.Namespace] = e
+	}
+	g.Expect(got).To(HaveLen(5))
+	g.Expect(got["preview-expired"].Disposition).To(Equal(DispositionWouldDelete))
+	g.Expect(got["preview-expired"].TTL).To(Equal("1h0m0s"))
+	g.Expect(got["preview-held"].Disposition).To(Equal(DispositionHeld))
+	g.Expect(got["preview-young"].Disposition).To(Equal(DispositionKept))
+	g.Expect(got["team-a"].Disposition).To(Equal(DispositionExcluded))
+	g.Expect(got["preview-bad-ttl"].Disposition).To(Equal(DispositionWouldDelete))
+	g.Expect(got["preview-bad-ttl"].Problems).To(ConsistOf(ContainSubstring(`"soon"`)))
+	g.Expect(got["preview-expired"].Problems).To(BeEmpty())
+}
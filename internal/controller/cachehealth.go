@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// watchFailureWindow is how long after its last failed List or watch the namespace informer
+// counts as unhealthy. The reflector retries at least every 30s while failing, so a lasting
+// outage keeps it unhealthy.
+const watchFailureWindow = time.Minute
+
+// namespaceType is how reflectors describe the namespace informer's type.
+var namespaceType = fmt.Sprintf("%T", &corev1.Namespace{})
+
+// WatchHealth notices a namespace informer that synced once but has since lost the apiserver,
+// which the cache's WaitForCacheSync keeps reporting as synced. Install Handler as the cache's
+// DefaultWatchErrorHandler and check Healthy along with the sync.
+type WatchHealth struct {
+	// UnixNano of the last failure, 0 if none
+	lastFailure atomic.Int64
+}
+
+// Handler records failures of the namespace informer, then passes every error on to client-go's
+// default handler, so logging is unchanged. Watches expiring or closing are routine, not failures.
+func (h *WatchHealth) Handler(ctx context.Context, r *toolscache.Reflector, err error) {
+	routine := apierrors.IsResourceExpired(err) || apierrors.IsGone(err) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+	if !routine && r.TypeDescription() == namespaceType {
+		h.lastFailure.Store(time.Now().UnixNano())
+	}
+	toolscache.DefaultWatchErrorHandler(ctx, r, err)
+}
+
+// Healthy reports whether the namespace informer went watchFailureWindow without failing.
+func (h *WatchHealth) Healthy(now time.Time) bool {
+	last := h.lastFailure.Load()
+	return last == 0 || now.Sub(time.Unix(0, last)) >= watchFailureWindow
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+func TestWatchHealth(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	nsReflector := toolscache.NewReflector(nil, &corev1.Namespace{}, toolscache.NewStore(toolscache.MetaNamespaceKeyFunc), 0)
+	podReflector := toolscache.NewReflector(nil, &corev1.Pod{}, toolscache.NewStore(toolscache.MetaNamespaceKeyFunc), 0)
+
+	h := &WatchHealth{}
+	g.Expect(h.Healthy(time.Now())).To(BeTrue())
+
+	// Routine watch ends and other informers' failures don't count
+	h.Handler(ctx, nsReflector, io.EOF)
+	h.Handler(ctx, nsReflector, apierrors.NewResourceExpired("too old resource version"))
+	h.Handler(ctx, podReflector, errors.New("connection refused"))
+	g.Expect(h.Healthy(time.Now())).To(BeTrue())
+
+	h.Handler(ctx, nsReflector, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "", errors.New("denied")))
+	g.Expect(h.Healthy(time.Now())).To(BeFalse())
+	g.Expect(h.Healthy(time.Now().Add(watchFailureWindow))).To(BeTrue(), "healthy again once the failures stop")
+}
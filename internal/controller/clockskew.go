@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+)
+
+var clockSkewSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "clock_skew_seconds",
+	Help: "Estimated apiserver clock minus controller clock at startup; positive means the controller is behind.",
+})
+
+func init() {
+	registerMetrics(clockSkewSeconds)
+}
+
+// EstimateClockSkew estimates how far the apiserver's clock is ahead of ours from the Date header
+// of a GET /version. The header has a one second resolution, so the estimate is only good to about
+// ±(0.5s + half the round trip). The result is exported as clock_skew_seconds.
+func EstimateClockSkew(ctx context.Context, cfg *rest.Config) (time.Duration, error) {
+	c, err := rest.HTTPClientFor(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("clock skew check: %w", err)
+	}
+	base, _, err := rest.DefaultServerUrlFor(cfg)
+	if err != nil {
+		return 0, fmt.Errorf("clock skew check: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.JoinPath("version").String(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("clock skew check: %w", err)
+	}
+
+	sent := time.Now()
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("clock skew check: %w", err)
+	}
+	received := time.Now()
+	_ = resp.Body.Close()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("clock skew check: apiserver Date header %q: %w", resp.Header.Get("Date"), err)
+	}
+	// The server's clock read somewhere in [date, date+1s), at about the middle of the round trip
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := date.Add(500 * time.Millisecond).Sub(local)
+
+	clockSkewSeconds.Set(skew.Seconds())
+	return skew, nil
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/rest"
+)
+
+func TestEstimateClockSkew(t *testing.T) {
+	g := NewWithT(t)
+
+	// The apiserver is ten minutes ahead of us
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/version"))
+		w.Header().Set("Date", time.Now().Add(10*time.Minute).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	skew, err := EstimateClockSkew(context.Background(), &rest.Config{Host: srv.URL})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(skew).To(BeNumerically("~", 10*time.Minute, 1500*time.Millisecond))
+	g.Expect(testutil.ToFloat64(clockSkewSeconds)).To(BeNumerically("~", 600, 1.5))
+
+	noDate := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Date"] = nil // suppress the header net/http adds
+	}))
+	defer noDate.Close()
+	_, err = EstimateClockSkew(context.Background(), &rest.Config{Host: noDate.URL})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestClockSkewCorrectsAges(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// 50m old by our clock, but 70m old by the apiserver's, which is 20m ahead
+	c := newFakeClient(nil, previewNS("preview-skewed", 50*time.Minute, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-skewed")).To(BeFalse())
+
+	s.ClockSkew = 20 * time.Minute
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-skewed")).To(BeTrue())
+}
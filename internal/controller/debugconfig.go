@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// EffectiveConfig is the live configuration ConfigHandler reports. It only holds settings that are
+// safe to show: no paths, URLs, commands or credentials.
+type EffectiveConfig struct {
+	Interval string `json:"interval"`
+	TTL      string `json:"ttl"`
+	// JitterPercent is a fraction, e.g. 0.05 for +-5%, like the field it reports
+	JitterPercent float64  `json:"jitterPercent"`
+	DryRun        bool     `json:"dryRun"`
+	Prefixes      []string `json:"prefixes"`
+	LabelKey      string   `json:"labelKey"`
+}
+
+// EffectiveConfig returns the configuration sweeps run with, after flags, env and defaults.
+func (s *NamespaceSweeper) EffectiveConfig() EffectiveConfig {
+	return EffectiveConfig{
+		Interval:      s.Interval.String(),
+		TTL:           s.TTL.String(),
+		JitterPercent: s.JitterPercent,
+		DryRun:        s.DryRun,
+		Prefixes:      s.prefixes(),
+		LabelKey:      s.EnableLabel(),
+	}
+}
+
+// ConfigHandler serves EffectiveConfig as JSON, so operators can confirm what is live without
+// reading the startup log. It only reads the sweeper's settings, so any replica can answer.
+func (s *NamespaceSweeper) ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "read-only, use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(s.EffectiveConfig())
+	})
+}
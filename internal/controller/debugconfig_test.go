@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestConfigHandler(t *testing.T) {
+	g := NewWithT(t)
+
+	s := &NamespaceSweeper{
+		TTL: 72 * time.Hour, Interval: 10 * time.Minute, JitterPercent: 0.05, DryRun: true,
+		Prefixes: []string{"preview-", "pr-"}, LabelKey: "example.com/sweep",
+		// None of these may leak
+		AuditOutput:  "/var/run/secret/report.json",
+		HoldRegistry: types.NamespacedName{Namespace: "ops", Name: "holds"},
+	}
+
+	rec := httptest.NewRecorder()
+	s.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+	g.Expect(rec.Body.String()).NotTo(Or(ContainSubstring("secret"), ContainSubstring("holds")))
+
+	var got map[string]any
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &got)).To(Succeed())
+	g.Expect(got).To(Equal(map[string]any{
+		"interval": "10m0s", "ttl": "72h0m0s", "jitterPercent": 0.05, "dryRun": true,
+		"prefixes": []any{"preview-", "pr-"}, "labelKey": "example.com/sweep",
+	}))
+
+	rec = httptest.NewRecorder()
+	s.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/config", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+}
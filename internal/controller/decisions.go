@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DecisionsHandler serves a fresh read-only evaluation of every preview namespace, the same one
+// --audit-only writes. It is Prometheus text by default, content-negotiated like /metrics, and
+// JSON for "Accept: application/json" or ?format=json. Each request runs a whole evaluation, so it
+// is meant for interactive debugging, not scraping.
+func (s *NamespaceSweeper) DecisionsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, ok := s.evaluate(r.Context(), s.Client)
+		if !ok {
+			http.Error(w, "evaluation failed, see the log", http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(report)
+			return
+		}
+		promhttp.HandlerFor(decisionsRegistry(report, s.MetricLabelMaxLen), promhttp.HandlerOpts{
+			ErrorHandling: promhttp.HTTPErrorOnError,
+		}).ServeHTTP(w, r)
+	})
+}
+
+// decisionsRegistry exposes report as gauges in a throwaway registry, with namespace labels
+// shortened to labelMaxLen like every other per-namespace series.
+func decisionsRegistry(report AuditReport, labelMaxLen int) *prometheus.Registry {
+	decision := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "decision",
+		Help:      "1 per namespace, labelled with what a sweep would decide for it and why.",
+	}, []string{"namespace", "disposition", "reason", "ttl_source"})
+	ttl := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "decision_ttl_seconds",
+		Help:      "Effective TTL of each namespace a TTL was resolved for.",
+	}, []string{"namespace"})
+	expires := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "decision_expires_timestamp_seconds",
+		Help:      "When each kept namespace's TTL and grace period run out, as a Unix timestamp.",
+	}, []string{"namespace"})
+	blocked := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "decision_blocked",
+		Help:      "1 with the reason while no namespace would be deleted, e.g. the kill switch.",
+	}, []string{"reason"})
+
+	for _, e := range report.Namespaces {
+		label := NormalizeLabelValue(e.Namespace, labelMaxLen)
+		decision.WithLabelValues(label, string(e.Disposition), e.Reason, e.TTLSource).Set(1)
+		if d, err := time.ParseDuration(e.TTL); err == nil {
+			ttl.WithLabelValues(label).Set(d.Seconds())
+		}
+		if e.ExpiresAt != nil {
+			expires.WithLabelValues(label).Set(float64(e.ExpiresAt.Unix()))
+		}
+	}
+	if report.Blocked != "" {
+		blocked.WithLabelValues(report.Blocked).Set(1)
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(decision, ttl, expires, blocked)
+	return reg
+}
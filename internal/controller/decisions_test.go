@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDecisionsHandler(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-old", 2*time.Hour, nil),
+		previewNS("preview-new", 10*time.Minute, nil),
+		previewNS("preview-held", 2*time.Hour, map[string]string{AnnotationHold: "true"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	sweeps := testutil.ToFloat64(sweepsTotal)
+	h := s.DecisionsHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/decisions", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	body := rec.Body.String()
+	g.Expect(body).To(ContainSubstring(`preview_sweeper_decision{disposition="would-delete",namespace="preview-old",reason="age exceeded TTL",ttl_source="default"} 1`))
+	g.Expect(body).To(ContainSubstring(`preview_sweeper_decision{disposition="held",namespace="preview-held"`))
+	g.Expect(body).To(ContainSubstring(`preview_sweeper_decision_ttl_seconds{namespace="preview-new"} 3600`))
+	g.Expect(body).To(ContainSubstring(`preview_sweeper_decision_expires_timestamp_seconds{namespace="preview-new"}`))
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/debug/decisions", nil)
+	req.Header.Set("Accept", "application/json")
+	h.ServeHTTP(rec, req)
+	g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+	var report AuditReport
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &report)).To(Succeed())
+	g.Expect(report.Namespaces).To(HaveLen(3))
+
+	// Read-only, and not counted as a sweep
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeFalse())
+	g.Expect(testutil.ToFloat64(sweepsTotal)).To(Equal(sweeps))
+	g.Expect(s.AuditOnly).To(BeFalse())
+
+	// Real sweeps still act afterwards
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+}
+
+func TestDecisionsNormalizeNamespaceLabels(t *testing.T) {
+	g := NewWithT(t)
+
+	long := "preview-feature-a-very-long-branch-name-that-goes-on"
+	c := newFakeClient(nil, previewNS(long, 10*time.Minute, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, MetricLabelMaxLen: 24}
+
+	rec := httptest.NewRecorder()
+	s.DecisionsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/decisions", nil))
+	label := NormalizeLabelValue(long, 24)
+	g.Expect(rec.Body.String()).To(ContainSubstring(`preview_sweeper_decision_ttl_seconds{namespace="` + label + `"} 3600`))
+	g.Expect(rec.Body.String()).NotTo(ContainSubstring(long))
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/sync/errgroup"
+)
+
+// deleteConcurrently runs the delete phase for toDelete, in order, on up to Concurrency workers,
+// passes each outcome to tally and returns how many namespaces were deleted. MaxDeletesPerSweep
+// is never exceeded: deletions in flight count against it, and once they would reach it the
+// remaining namespaces wait for those to finish, as failed ones don't count.
+func (s *NamespaceSweeper) deleteConcurrently(ctx context.Context, logger logr.Logger, toDelete []expiredNamespace,
+	blocked string, tally func(e expiredNamespace, deleted, dryRun bool)) int {
+	var (
+		mu                sync.Mutex
+		deleted, inFlight int
+	)
+	reserve := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if s.MaxDeletesPerSweep > 0 && deleted+inFlight >= s.MaxDeletesPerSweep {
+			return false
+		}
+		inFlight++
+		return true
+	}
+
+	var g errgroup.Group
+	g.SetLimit(s.Concurrency)
+	for i, e := range toDelete {
+		if ctx.Err() != nil {
+			logger.Info("Stopping deletions", "reason", ctx.Err().Error(), "remaining", len(toDelete)-i)
+			break
+		}
+		if !reserve() {
+			_ = g.Wait()
+			if !reserve() {
+				logger.Info("Reached the per-sweep deletion cap, deferring the rest to the next sweep",
+					"maxDeletesPerSweep", s.MaxDeletesPerSweep, "deferred", len(toDelete)-i)
+				break
+			}
+		}
+		g.Go(func() error {
+			ok, dryRun := s.deleteExpired(ctx, logger, e, blocked)
+			tally(e, ok, dryRun)
+			mu.Lock()
+			defer mu.Unlock()
+			inFlight--
+			if ok {
+				deleted++
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return deleted
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestDeleteConcurrency(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var inFlight, maxInFlight atomic.Int32
+	var objs []client.Object
+	for i := range 8 {
+		objs = append(objs, previewNS(fmt.Sprintf("preview-%d", i), 2*time.Hour, nil))
+	}
+	c := newFakeClient(&interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return c.Delete(ctx, obj, opts...)
+		},
+	}, objs...)
+	logRef := types.NamespacedName{Namespace: "platform", Name: "preview-deletions"}
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Concurrency: 4, DeletionLog: logRef}
+
+	res := s.SweepOnce(ctx)
+	g.Expect(res.Deleted).To(Equal(8))
+	g.Expect(maxInFlight.Load()).To(BeNumerically(">", 1))
+	g.Expect(maxInFlight.Load()).To(BeNumerically("<=", 4))
+	g.Expect(deletionLogRecords(g, ctx, c, logRef)).To(HaveLen(8))
+}
+
+func TestDeleteConcurrencyHonorsCap(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var objs []client.Object
+	for i := range 8 {
+		objs = append(objs, previewNS(fmt.Sprintf("preview-%d", i), time.Duration(10-i)*time.Hour, nil))
+	}
+	var attempts atomic.Int32
+	c := newFakeClient(&interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			attempts.Add(1)
+			// The two oldest fail, so the cap is reached with later ones
+			if obj.GetName() == "preview-0" || obj.GetName() == "preview-1" {
+				return errors.New("admission webhook denied the request")
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	}, objs...)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Concurrency: 3, MaxDeletesPerSweep: 3}
+
+	res := s.SweepOnce(ctx)
+	g.Expect(res.Deleted).To(Equal(3))
+	g.Expect(attempts.Load()).To(BeNumerically("<=", 6))
+	for _, name := range []string{"preview-7", "preview-6"} {
+		g.Expect(isDeleted(ctx, c, name)).To(BeFalse())
+	}
+}
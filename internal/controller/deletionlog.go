@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// DeletionLogKey is the DeletionLog ConfigMap key holding one JSON DeletionRecord per line,
+	// oldest first.
+	DeletionLogKey = "deletions.jsonl"
+	// DefaultDeletionLogMaxEntries is how many records the DeletionLog keeps unless
+	// DeletionLogMaxEntries is set.
+	DefaultDeletionLogMaxEntries = 500
+
+	// deletionLogTimeout bounds writing a sweep's records, which outlives the sweep's context.
+	deletionLogTimeout = 10 * time.Second
+)
+
+// DeletionRecord is one entry of the DeletionLog: a namespace deleted, or one dry-run would have.
+type DeletionRecord struct {
+	Namespace string    `json:"namespace"`
+	DeletedAt time.Time `json:"deletedAt"`
+	Age       string    `json:"age"`
+	TTL       string    `json:"ttl"`
+	TTLSource string    `json:"ttlSource"`
+	DryRun    bool      `json:"dryRun,omitempty"`
+	// Controller is the ControllerID of the replica that deleted it.
+	Controller string `json:"controller,omitempty"`
+}
+
+// appendDeletionLog appends records to the DeletionLog ConfigMap, creating it if needed, and
+// trims it to the most recent DeletionLogMaxEntries records. Conflicting writes, e.g. from another
+// replica, are retried on a fresh copy.
+func (s *NamespaceSweeper) appendDeletionLog(ctx context.Context, records []DeletionRecord) error {
+	maxEntries := s.DeletionLogMaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultDeletionLogMaxEntries
+	}
+	var added []string
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("encoding deletion record: %w", err)
+		}
+		added = append(added, string(line))
+	}
+
+	retriable := func(err error) bool { return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) }
+	return retry.OnError(retry.DefaultBackoff, retriable, func() error {
+		var cm corev1.ConfigMap
+		err := s.Client.Get(ctx, s.DeletionLog, &cm)
+		if apierrors.IsNotFound(err) {
+			cm = corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: s.DeletionLog.Namespace, Name: s.DeletionLog.Name},
+				Data:       map[string]string{DeletionLogKey: joinDeletionLog(nil, added, maxEntries)},
+			}
+			return s.Client.Create(ctx, &cm, s.fieldOwner())
+		}
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[DeletionLogKey] = joinDeletionLog(splitDeletionLog(cm.Data[DeletionLogKey]), added, maxEntries)
+		return s.Client.Update(ctx, &cm, s.fieldOwner())
+	})
+}
+
+func splitDeletionLog(raw string) []string {
+	var lines []string
+	for line := range strings.Lines(raw) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// joinDeletionLog appends added to lines, drops the oldest lines beyond maxEntries and renders
+// the result with a trailing newline.
+func joinDeletionLog(lines, added []string, maxEntries int) string {
+	lines = append(lines, added...)
+	if len(lines) > maxEntries {
+		lines = lines[len(lines)-maxEntries:]
+	}
+	var b bytes.Buffer
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
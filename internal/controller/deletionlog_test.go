@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func deletionLogRecords(g *WithT, ctx context.Context, c client.Client, ref types.NamespacedName) []DeletionRecord {
+	var cm corev1.ConfigMap
+	g.Expect(c.Get(ctx, ref, &cm)).To(Succeed())
+	var records []DeletionRecord
+	for _, line := range strings.Split(strings.TrimSpace(cm.Data[DeletionLogKey]), "\n") {
+		var r DeletionRecord
+		g.Expect(json.Unmarshal([]byte(line), &r)).To(Succeed())
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestDeletionLog(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	ref := types.NamespacedName{Namespace: "platform", Name: "preview-deletions"}
+	conflicts := 1
+	c := newFakeClient(&interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if conflicts > 0 {
+				conflicts--
+				return apierrors.NewConflict(corev1.Resource("configmaps"), obj.GetName(), nil)
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	},
+		previewNS("preview-a", 3*time.Hour, nil),
+		previewNS("preview-b", 2*time.Hour, map[string]string{AnnotationEnforce: "false"}),
+		previewNS("preview-young", 10*time.Minute, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, DeletionLog: ref, DeletionLogMaxEntries: 2, ControllerID: "sweeper-0"}
+
+	// Created on first use
+	s.SweepOnce(ctx)
+	records := deletionLogRecords(g, ctx, c, ref)
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0].Namespace).To(Equal("preview-a"))
+	g.Expect(records[0].TTL).To(Equal("1h0m0s"))
+	g.Expect(records[0].TTLSource).To(Equal("default"))
+	g.Expect(records[0].DryRun).To(BeFalse())
+	g.Expect(records[0].Controller).To(Equal("sweeper-0"))
+	g.Expect(records[0].DeletedAt).To(BeTemporally("~", time.Now(), 5*time.Second))
+	g.Expect(records[1].Namespace).To(Equal("preview-b"))
+	g.Expect(records[1].DryRun).To(BeTrue())
+
+	// Appended after a conflict and trimmed to the newest entries
+	g.Expect(c.Create(ctx, previewNS("preview-c", 90*time.Minute, nil))).To(Succeed())
+	s.SweepOnce(ctx)
+	records = deletionLogRecords(g, ctx, c, ref)
+	g.Expect(conflicts).To(BeZero())
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0].Namespace).To(Equal("preview-b"))
+	g.Expect(records[1].Namespace).To(Equal("preview-c"))
+}
+
+func TestDeletionLogAfterCancelledSweep(t *testing.T) {
+	g := NewWithT(t)
+
+	ref := types.NamespacedName{Namespace: "platform", Name: "preview-deletions"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newFakeClient(&interceptor.Funcs{
+		// Shutdown arrives while the first deletion is in flight
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			cancel()
+			return c.Delete(ctx, obj, opts...)
+		},
+		// Like a real client, don't write with a cancelled context
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	},
+		previewNS("preview-a", 3*time.Hour, nil),
+		previewNS("preview-b", 3*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, DeletionLog: ref}
+	g.Expect(s.SweepOnce(ctx).Deleted).To(Equal(1))
+
+	records := deletionLogRecords(g, context.Background(), c, ref)
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Namespace).To(Equal("preview-a"))
+}
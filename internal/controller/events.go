@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// EventMessageData is what an --event-message-template is rendered with.
+type EventMessageData struct {
+	Namespace  *corev1.Namespace
+	Age        time.Duration
+	TTL        time.Duration
+	TTLSource  string
+	Reason     string
+	DryRun     bool
+	Controller string
+}
+
+// ParseEventMessageTemplate parses and test-renders an --event-message-template, e.g.
+// `Deleted {{.Namespace.Name}} after {{.Age}}, PR {{index .Namespace.Annotations "pr-url"}}`.
+func ParseEventMessageTemplate(raw string) (*template.Template, error) {
+	t, err := template.New("event-message").Option("missingkey=zero").Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("event message template: %w", err)
+	}
+	sample := EventMessageData{Namespace: &corev1.Namespace{}, Age: 2 * time.Hour, TTL: time.Hour, TTLSource: "default",
+		Reason: "age 2h0m0s exceeded TTL 1h0m0s (default)"}
+	if err := t.Execute(&strings.Builder{}, sample); err != nil {
+		return nil, fmt.Errorf("event message template: %w", err)
+	}
+	return t, nil
+}
+
+// deletionMessage renders EventMessageTemplate for a (dry-run) deletion event, falling back
+// to def when there is no template or it fails to render.
+func (s *NamespaceSweeper) deletionMessage(logger logr.Logger, data EventMessageData, def string) string {
+	if s.EventMessageTemplate == nil {
+		return def
+	}
+	var sb strings.Builder
+	if err := s.EventMessageTemplate.Execute(&sb, data); err != nil {
+		logger.Error(err, "Failed to render event message template, using the default message", "name", data.Namespace.Name)
+		return def
+	}
+	return sb.String()
+}
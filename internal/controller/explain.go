@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Explanation is what a sweep would decide for one namespace, and why.
+type Explanation struct {
+	Namespace *corev1.Namespace
+	// Entry is nil when the namespace lacks the enable label, so sweeps never list it.
+	Entry *AuditEntry
+	// Blocked is why no namespace would be deleted right now, e.g. the kill switch.
+	Blocked string
+}
+
+// Explain runs one audit-only evaluation restricted to the namespace called name, so the answer
+// comes from the exact pipeline real sweeps use.
+func (s *NamespaceSweeper) Explain(ctx context.Context, name string) (Explanation, error) {
+	var ns corev1.Namespace
+	if err := s.Client.Get(ctx, client.ObjectKey{Name: name}, &ns); err != nil {
+		return Explanation{}, fmt.Errorf("getting namespace %q: %w", name, err)
+	}
+
+	report, ok := s.evaluate(ctx, singleNamespaceClient{Client: s.Client, name: name})
+	if !ok {
+		return Explanation{}, fmt.Errorf("evaluating namespace %q failed, see the log", name)
+	}
+	e := Explanation{Namespace: &ns, Blocked: report.Blocked}
+	if i := slices.IndexFunc(report.Namespaces, func(a AuditEntry) bool { return a.Namespace == name }); i >= 0 {
+		e.Entry = &report.Namespaces[i]
+	}
+	return e, nil
+}
+
+// evaluate runs an audit-only sweep through c without deleting or writing anything, leaving
+// metrics and the state carried between sweeps alone. It waits for a running sweep to finish,
+// and reports false if the sweep could not list namespaces.
+func (s *NamespaceSweeper) evaluate(ctx context.Context, c client.Client) (AuditReport, bool) {
+	s.sweepMu.Lock()
+	defer s.sweepMu.Unlock()
+	return s.evaluateLocked(ctx, c)
+}
+
+// evaluateLocked is evaluate for callers already holding sweepMu, i.e. a running sweep.
+func (s *NamespaceSweeper) evaluateLocked(ctx context.Context, c client.Client) (AuditReport, bool) {
+	auditOnly, guard, orig := s.AuditOnly, s.SweepGuard, s.Client
+	defer func() {
+		s.AuditOnly, s.SweepGuard, s.Client, s.auditSink = auditOnly, guard, orig, nil
+	}()
+	var report AuditReport
+	delivered := false
+	s.AuditOnly = true
+	s.SweepGuard = types.NamespacedName{}
+	s.auditSink = func(r AuditReport) { report, delivered = r, true }
+	s.Client = c
+	s.sweep(ctx)
+	return report, delivered
+}
+
+// singleNamespaceClient lists only the namespace called name.
+type singleNamespaceClient struct {
+	client.Client
+	name string
+}
+
+func (c singleNamespaceClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	if l, ok := list.(*corev1.NamespaceList); ok {
+		l.Items = slices.DeleteFunc(l.Items, func(ns corev1.Namespace) bool { return ns.Name != c.name })
+	}
+	return nil
+}
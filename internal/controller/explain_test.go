@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestExplain(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	unlabeled := previewNS("preview-unlabeled", 3*time.Hour, nil)
+	unlabeled.Labels = nil
+	c := newFakeClient(nil,
+		previewNS("preview-short", 40*time.Minute, map[string]string{AnnotationTTL: "10m"}),
+		previewNS("preview-expired", 3*time.Hour, nil),
+		unlabeled,
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, MinTTL: time.Hour, KillSwitchFile: t.TempDir()}
+
+	e, err := s.Explain(ctx, "preview-short")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(e.Entry).NotTo(BeNil())
+	g.Expect(e.Entry.Disposition).To(Equal(DispositionKept))
+	g.Expect(e.Entry.TTL).To(Equal("1h0m0s"))
+	g.Expect(e.Entry.Steps).To(Equal([]string{"resolved TTL 10m0s from annotation", "raised to --min-ttl 1h0m0s"}))
+	g.Expect(*e.Entry.ExpiresAt).To(BeTemporally("~", e.Namespace.CreationTimestamp.Add(time.Hour), time.Second))
+	g.Expect(e.Blocked).To(ContainSubstring("kill switch"))
+
+	// Explaining never acts, not even on other expired namespaces
+	e, err = s.Explain(ctx, "preview-expired")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(e.Entry.Disposition).To(Equal(DispositionWouldDelete))
+	g.Expect(isDeleted(ctx, c, "preview-expired")).To(BeFalse())
+
+	e, err = s.Explain(ctx, "preview-unlabeled")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(e.Entry).To(BeNil())
+
+	_, err = s.Explain(ctx, "preview-missing")
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+	// The sweeper is left as it was
+	g.Expect(s.AuditOnly).To(BeFalse())
+	s.KillSwitchFile = ""
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-expired")).To(BeTrue())
+}
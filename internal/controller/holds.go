@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ParseConfigMapRef parses a ConfigMap flag value such as --hold-registry-configmap of the
+// form "namespace/name".
+func ParseConfigMapRef(raw string) (types.NamespacedName, error) {
+	return parseObjectRef("configmap", raw)
+}
+
+func parseObjectRef(kind, raw string) (types.NamespacedName, error) {
+	namespace, name, ok := strings.Cut(raw, "/")
+	if !ok || namespace == "" || name == "" {
+		return types.NamespacedName{}, fmt.Errorf("%s %q: want namespace/name", kind, raw)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
+// Hold sources, as reported by holdOf.
+const (
+	HoldSourceAnnotation = "annotation"
+	HoldSourceRegistry   = "registry"
+)
+
+// holdOf reports whether ns is held at now, by the hold registry or AnnotationHold, in that order
+// of precedence, with the source and the reason (registry holds only).
+func holdOf(ns *corev1.Namespace, registryHolds map[string]string, now time.Time) (source, reason string, held bool) {
+	if reason, ok := registryHolds[ns.Name]; ok {
+		return HoldSourceRegistry, reason, true
+	}
+	if until, ok := holdUntil(ns.Annotations); ok && (until.IsZero() || now.Before(until)) {
+		return HoldSourceAnnotation, "", true
+	}
+	return "", "", false
+}
+
+// holdUntil parses AnnotationHold: "true" holds a namespace indefinitely, returned as a zero
+// time, and an RFC 3339 time holds it until then. ok is false for an absent or unrecognized
+// value, which holds nothing.
+func holdUntil(annotations map[string]string) (until time.Time, ok bool) {
+	raw, set := annotations[AnnotationHold]
+	if !set {
+		return time.Time{}, false
+	}
+	if raw == "true" {
+		return time.Time{}, true
+	}
+	until, err := time.Parse(time.RFC3339, strings.TrimSpace(raw))
+	return until, err == nil
+}
+
+// heldEventf records a NamespaceCleanupHeld event for a namespace skipped because of its hold
+// annotation key, AnnotationHold or the one of its SweepPolicy, only once per hold so a long hold
+// doesn't flood the event stream. See pruneHeldEvented.
+func (s *NamespaceSweeper) heldEventf(ns *corev1.Namespace, key string, ttl time.Duration, ttlSource string) {
+	if s.Recorder == nil || s.AuditOnly {
+		return
+	}
+	if _, ok := s.heldEvented[ns.UID]; ok {
+		return
+	}
+	if s.heldEvented == nil {
+		s.heldEvented = map[types.UID]struct{}{}
+	}
+	s.heldEvented[ns.UID] = struct{}{}
+	s.eventf(ns, corev1.EventTypeNormal, "NamespaceCleanupHeld",
+		"Not swept: %s=%s (effective TTL %s from %s)", key, ns.Annotations[key], ttl, ttlSource)
+}
+
+// pruneHeldEvented forgets the namespaces a sweep didn't find held by annotation, whether they
+// were released or deleted, so a later hold gets its own event and the map doesn't grow.
+func (s *NamespaceSweeper) pruneHeldEvented(held map[types.UID]struct{}) {
+	for uid := range s.heldEvented {
+		if _, ok := held[uid]; !ok {
+			delete(s.heldEvented, uid)
+		}
+	}
+}
+
+// loadHoldRegistry returns the held namespaces from the HoldRegistry ConfigMap, mapped to the
+// reason for the hold. A missing ConfigMap is an empty registry.
+func (s *NamespaceSweeper) loadHoldRegistry(ctx context.Context) (map[string]string, error) {
+	cm, err := s.holdRegistryConfigMap(ctx)
+	if cm == nil {
+		return nil, err
+	}
+	return cm.Data, nil
+}
+
+// holdRegistryConfigMap gets the HoldRegistry ConfigMap; nil if none is configured or it is missing.
+func (s *NamespaceSweeper) holdRegistryConfigMap(ctx context.Context) (*corev1.ConfigMap, error) {
+	if s.HoldRegistry.Name == "" {
+		return nil, nil
+	}
+	var cm corev1.ConfigMap
+	if err := s.Client.Get(ctx, s.HoldRegistry, &cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cm, nil
+}
+
+// Hold is a held namespace, as served on /holds.
+type Hold struct {
+	Namespace string `json:"namespace"`
+	// Source is HoldSourceAnnotation or HoldSourceRegistry.
+	Source string `json:"source"`
+	Reason string `json:"reason,omitempty"`
+	// Since is when the hold was last written, from the managedFields of the namespace or the
+	// registry ConfigMap. Absent when unknown, e.g. for objects created without managedFields.
+	Since *time.Time `json:"since,omitempty"`
+	// Until is when an annotation hold set to a time ends; absent for indefinite holds.
+	Until *time.Time `json:"until,omitempty"`
+	// Missing marks registry entries naming no namespace with the enable label, left for cleanup.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Holds lists every hold in force on a namespace carrying the enable label, whatever the other rules would
+// decide for it, plus registry entries naming no such namespace. A namespace held both ways is
+// listed once per source. It only reads, and doesn't wait for sweeps.
+func (s *NamespaceSweeper) Holds(ctx context.Context) ([]Hold, error) {
+	var nsList corev1.NamespaceList
+	sel := labels.SelectorFromSet(labels.Set{s.EnableLabel(): "true"})
+	if err := s.Client.List(ctx, &nsList, &client.ListOptions{LabelSelector: sel}); err != nil {
+		return nil, fmt.Errorf("listing namespaces: %w", err)
+	}
+	registry, err := s.holdRegistryConfigMap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading hold registry %s: %w", s.HoldRegistry, err)
+	}
+
+	holds := []Hold{}
+	listed := make(map[string]bool, len(nsList.Items))
+	now := time.Now().Add(s.ClockSkew)
+	for i := range nsList.Items {
+		ns := &nsList.Items[i]
+		listed[ns.Name] = true
+		if until, ok := holdUntil(ns.Annotations); ok && (until.IsZero() || now.Before(until)) {
+			h := Hold{Namespace: ns.Name, Source: HoldSourceAnnotation,
+				Since: fieldSetAt(ns.ManagedFields, "f:metadata", "f:annotations", "f:"+AnnotationHold)}
+			if !until.IsZero() {
+				h.Until = &until
+			}
+			holds = append(holds, h)
+		}
+	}
+	if registry != nil {
+		for name, reason := range registry.Data {
+			holds = append(holds, Hold{Namespace: name, Source: HoldSourceRegistry, Reason: reason, Missing: !listed[name],
+				Since: fieldSetAt(registry.ManagedFields, "f:data", "f:"+name)})
+		}
+	}
+	slices.SortFunc(holds, func(a, b Hold) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Source, b.Source))
+	})
+	return holds, nil
+}
+
+// HoldsHandler serves Holds as JSON.
+func (s *NamespaceSweeper) HoldsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		holds, err := s.Holds(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(holds)
+	})
+}
+
+// fieldSetAt returns the time of the latest managedFields entry owning the field at path, e.g.
+// "f:metadata", "f:annotations", "f:<key>".
+func fieldSetAt(entries []metav1.ManagedFieldsEntry, path ...string) *time.Time {
+	var latest *time.Time
+	for _, mf := range entries {
+		if mf.Time == nil || mf.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		owned := true
+		for _, key := range path {
+			next, ok := fields[key].(map[string]any)
+			if !ok {
+				owned = false
+				break
+			}
+			fields = next
+		}
+		if owned && (latest == nil || mf.Time.After(*latest)) {
+			t := mf.Time.Time
+			latest = &t
+		}
+	}
+	return latest
+}
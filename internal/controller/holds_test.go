@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestHoldsHandler(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	heldAt := metav1.NewTime(time.Now().Add(-3 * time.Hour).Truncate(time.Second))
+	annotated := previewNS("preview-annotation", 2*time.Hour, map[string]string{AnnotationHold: "true"})
+	annotated.ManagedFields = []metav1.ManagedFieldsEntry{{
+		Manager: "kubectl-annotate", Operation: metav1.ManagedFieldsOperationUpdate, Time: &heldAt,
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:annotations":{"f:` + AnnotationHold + `":{}}}}`)},
+	}}
+	registry := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "preview-holds"},
+		Data: map[string]string{
+			"preview-registry": "customer demo",
+			"preview-gone":     "release freeze",
+		},
+	}
+	c := newFakeClient(nil,
+		registry,
+		annotated,
+		previewNS("preview-registry", 2*time.Hour, nil),
+		previewNS("preview-free", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, HoldRegistry: client.ObjectKeyFromObject(registry)}
+
+	rec := httptest.NewRecorder()
+	s.HoldsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/holds", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+	var holds []Hold
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &holds)).To(Succeed())
+
+	g.Expect(holds).To(HaveLen(3))
+	g.Expect(holds[0].Namespace).To(Equal("preview-annotation"))
+	g.Expect(holds[0].Source).To(Equal(HoldSourceAnnotation))
+	g.Expect(holds[0].Since).NotTo(BeNil())
+	g.Expect(*holds[0].Since).To(BeTemporally("==", heldAt.Time))
+	g.Expect(holds[1]).To(Equal(Hold{Namespace: "preview-gone", Source: HoldSourceRegistry, Reason: "release freeze", Missing: true}))
+	g.Expect(holds[2]).To(Equal(Hold{Namespace: "preview-registry", Source: HoldSourceRegistry, Reason: "customer demo"}))
+
+	// Read-only
+	g.Expect(isDeleted(ctx, c, "preview-free")).To(BeFalse())
+}
+
+func TestHeldEventOncePerNamespace(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	held := previewNS("preview-held", 2*time.Hour, map[string]string{AnnotationHold: "true", AnnotationTTL: "1h"})
+	held.UID = "uid-held"
+	other := previewNS("preview-other", 2*time.Hour, map[string]string{AnnotationHold: "true"})
+	other.UID = "uid-other"
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: newFakeClient(nil, held, other), TTL: 3 * time.Hour, Recorder: rec}
+
+	for range 3 {
+		s.SweepOnce(ctx)
+	}
+
+	var events []string
+	for len(rec.Events) > 0 {
+		events = append(events, <-rec.Events)
+	}
+	g.Expect(events).To(ConsistOf(
+		"Normal NamespaceCleanupHeld Not swept: "+AnnotationHold+"=true (effective TTL 1h0m0s from annotation)",
+		"Normal NamespaceCleanupHeld Not swept: "+AnnotationHold+"=true (effective TTL 3h0m0s from default)",
+	))
+	g.Expect(isDeleted(ctx, s.Client, "preview-held")).To(BeFalse())
+
+	// Released namespaces are forgotten, and a new hold gets a new event
+	var ns corev1.Namespace
+	g.Expect(s.Client.Get(ctx, client.ObjectKey{Name: "preview-other"}, &ns)).To(Succeed())
+	delete(ns.Annotations, AnnotationHold)
+	g.Expect(s.Client.Update(ctx, &ns)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(s.heldEvented).To(HaveLen(1))
+	g.Expect(s.heldEvented).To(HaveKey(held.UID))
+
+	ns.Annotations = map[string]string{AnnotationHold: "true"}
+	g.Expect(s.Client.Update(ctx, &ns)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(rec.Events).To(Receive(ContainSubstring("NamespaceCleanupHeld")))
+	g.Expect(rec.Events).To(BeEmpty())
+}
+
+func TestHoldUntil(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	future := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	c := newFakeClient(nil,
+		previewNS("preview-held-until-future", 2*time.Hour, map[string]string{AnnotationHold: future}),
+		previewNS("preview-held-until-past", 2*time.Hour, map[string]string{AnnotationHold: past}),
+		previewNS("preview-held-forever", 2*time.Hour, map[string]string{AnnotationHold: "true"}),
+		previewNS("preview-held-typo", 2*time.Hour, map[string]string{AnnotationHold: "until friday"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	holds, err := s.Holds(ctx)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(holds).To(HaveLen(2))
+	g.Expect(holds[0].Namespace).To(Equal("preview-held-forever"))
+	g.Expect(holds[0].Until).To(BeNil())
+	g.Expect(holds[1].Namespace).To(Equal("preview-held-until-future"))
+	g.Expect(holds[1].Until.Format(time.RFC3339)).To(Equal(future))
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-held-until-future")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-held-forever")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-held-until-past")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-held-typo")).To(BeTrue())
+}
+
+func TestExpiredHoldLog(t *testing.T) {
+	g := NewWithT(t)
+
+	var lines []string
+	logger := funcr.New(func(prefix, args string) { lines = append(lines, args) }, funcr.Options{Verbosity: 1})
+	ctx := log.IntoContext(context.Background(), logger)
+
+	past := map[string]string{AnnotationHold: time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)}
+	registry := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "preview-holds"},
+		Data:       map[string]string{"preview-registry-held": "customer demo"},
+	}
+	c := newFakeClient(nil, registry,
+		previewNS("preview-registry-held", 2*time.Hour, past),
+		previewNS("preview-hold-over", 2*time.Hour, past),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, HoldRegistry: client.ObjectKeyFromObject(registry)}
+	s.SweepOnce(ctx)
+
+	var expired []string
+	for _, l := range lines {
+		if strings.Contains(l, "Hold has expired") {
+			expired = append(expired, l)
+		}
+	}
+	g.Expect(expired).To(HaveLen(1))
+	g.Expect(expired[0]).To(ContainSubstring(`"name"="preview-hold-over"`))
+	g.Expect(expired[0]).To(ContainSubstring(`"level"=1`))
+}
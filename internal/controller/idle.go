@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// idleFor is how long ns has had no Pods as of now, recording when it last had some into busy.
+// Pods that finished (Succeeded or Failed) or are being deleted don't count. A namespace seen
+// empty for the first time, e.g. after a restart, is idle from now: the sweeper can't tell how
+// long it has been empty. Tracking is keyed by UID, so a namespace recreated under the same name
+// starts afresh.
+func (s *NamespaceSweeper) idleFor(ctx context.Context, ns *corev1.Namespace, now time.Time, busy map[types.UID]time.Time) (time.Duration, error) {
+	last, seen := s.lastBusy[ns.UID]
+	if !seen {
+		last = now
+	}
+	busy[ns.UID] = last
+
+	var pods corev1.PodList
+	if err := s.Client.List(ctx, &pods, client.InNamespace(ns.Name)); err != nil {
+		return 0, err
+	}
+	for i := range pods.Items {
+		if podActive(&pods.Items[i]) {
+			busy[ns.UID] = now
+			return 0, nil
+		}
+	}
+	return now.Sub(last), nil
+}
+
+func podActive(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
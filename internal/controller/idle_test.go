@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIdleTTL(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	withUID := func(ns *corev1.Namespace, uid types.UID) *corev1.Namespace {
+		ns.UID = uid
+		return ns
+	}
+	pod := func(ns, name string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name}, Status: corev1.PodStatus{Phase: phase}}
+	}
+	c := newFakeClient(nil,
+		withUID(previewNS("preview-busy", 3*time.Hour, nil), "busy"),
+		withUID(previewNS("preview-empty", 3*time.Hour, nil), "empty"),
+		withUID(previewNS("preview-done", 3*time.Hour, nil), "done"),
+		withUID(previewNS("preview-recreated", 3*time.Hour, nil), "recreated-2"),
+		pod("preview-busy", "web", corev1.PodRunning),
+		pod("preview-done", "migrate", corev1.PodSucceeded),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: 24 * time.Hour, IdleTTL: time.Hour}
+
+	// Seen empty for the first time: idle from now, nothing goes yet
+	s.SweepOnce(ctx)
+	for _, name := range []string{"preview-busy", "preview-empty", "preview-done", "preview-recreated"} {
+		g.Expect(isDeleted(ctx, c, name)).To(BeFalse(), name)
+	}
+	g.Expect(s.lastBusy).To(HaveLen(4))
+
+	// Two hours on; the recreated namespace's state belongs to its predecessor's UID
+	for uid := range s.lastBusy {
+		s.lastBusy[uid] = s.lastBusy[uid].Add(-2 * time.Hour)
+	}
+	delete(s.lastBusy, "recreated-2")
+	s.lastBusy["recreated-1"] = time.Now().Add(-2 * time.Hour)
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-busy")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-empty")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-done")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-recreated")).To(BeFalse())
+	g.Expect(s.lastBusy).NotTo(HaveKey(types.UID("recreated-1")))
+	g.Expect(s.lastBusy["busy"]).To(BeTemporally("~", time.Now(), time.Minute))
+}
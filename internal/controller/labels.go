@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// minMetricLabelLen leaves room for a readable prefix besides the "-" and 8-char hash suffix.
+const minMetricLabelLen = 16
+
+// NormalizeLabelValue shortens a namespace name used as a metric label value to at most maxLen
+// characters. Longer names keep their prefix and get a hash of the full name appended, so distinct
+// namespaces never share a series. maxLen <= 0 disables it; values below 16 are raised to 16.
+// Only metric labels are normalized; logs and events always carry the raw name.
+func NormalizeLabelValue(name string, maxLen int) string {
+	if maxLen <= 0 || len(name) <= maxLen {
+		return name
+	}
+	maxLen = max(maxLen, minMetricLabelLen)
+	if len(name) <= maxLen {
+		return name
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return fmt.Sprintf("%s-%08x", name[:maxLen-9], h.Sum32())
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNormalizeLabelValue(t *testing.T) {
+	g := NewWithT(t)
+
+	long := "preview-" + strings.Repeat("feature-branch-", 3) + "a"
+	other := "preview-" + strings.Repeat("feature-branch-", 3) + "b"
+
+	g.Expect(NormalizeLabelValue("preview-short", 32)).To(Equal("preview-short"))
+	g.Expect(NormalizeLabelValue(long, 0)).To(Equal(long), "0 disables normalization")
+
+	got := NormalizeLabelValue(long, 32)
+	g.Expect(got).To(HaveLen(32))
+	g.Expect(got).To(HavePrefix("preview-feature-branch-"))
+	g.Expect(NormalizeLabelValue(long, 32)).To(Equal(got), "normalization is deterministic")
+	g.Expect(NormalizeLabelValue(other, 32)).NotTo(Equal(got), "same prefix must not collide")
+
+	g.Expect(NormalizeLabelValue(long, 4)).To(HaveLen(minMetricLabelLen))
+}
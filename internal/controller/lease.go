@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ParseLeaseRef parses the --liveness-lease flag value, "namespace/name".
+func ParseLeaseRef(raw string) (types.NamespacedName, error) {
+	return parseObjectRef("lease", raw)
+}
+
+// renewLivenessLease sets the renewTime of the LivenessLease to now, creating the Lease if needed.
+// The Lease only advertises that sweeps are making progress; nothing ever waits on it, so it is
+// unrelated to leader election. Its leaseDurationSeconds is twice the sweep interval, a hint for
+// tooling on when to consider it stale.
+func (s *NamespaceSweeper) renewLivenessLease(ctx context.Context, now time.Time) error {
+	renewed := metav1.NewMicroTime(now)
+	holder := s.ControllerID
+	duration := int32(min(math.Ceil(2*s.Interval.Seconds()), math.MaxInt32))
+
+	var lease coordinationv1.Lease
+	err := s.Client.Get(ctx, s.LivenessLease, &lease)
+	if apierrors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: s.LivenessLease.Namespace, Name: s.LivenessLease.Name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity: &holder, LeaseDurationSeconds: &duration, AcquireTime: &renewed, RenewTime: &renewed,
+			},
+		}
+		return s.Client.Create(ctx, &lease, s.fieldOwner())
+	}
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder {
+		// Another replica, e.g. the previous leader, renewed it last
+		lease.Spec.HolderIdentity = &holder
+		lease.Spec.AcquireTime = &renewed
+	}
+	lease.Spec.LeaseDurationSeconds = &duration
+	lease.Spec.RenewTime = &renewed
+	return s.Client.Update(ctx, &lease, s.fieldOwner())
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestLivenessLeaseRenewedAfterSweep(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	failList := false
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if failList {
+				return errors.New("apiserver unavailable")
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}, previewNS("preview-a", 10*time.Minute, nil))
+	ref := types.NamespacedName{Namespace: "preview-sweeper", Name: "sweeper-liveness"}
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Interval: 5 * time.Minute, ControllerID: "sweeper-0", LivenessLease: ref}
+
+	var lease coordinationv1.Lease
+	s.SweepOnce(ctx)
+	g.Expect(c.Get(ctx, ref, &lease)).To(Succeed())
+	g.Expect(*lease.Spec.HolderIdentity).To(Equal("sweeper-0"))
+	g.Expect(*lease.Spec.LeaseDurationSeconds).To(Equal(int32(600)))
+	first := lease.Spec.RenewTime.Time
+
+	time.Sleep(10 * time.Millisecond)
+	s.SweepOnce(ctx)
+	g.Expect(c.Get(ctx, ref, &lease)).To(Succeed())
+	g.Expect(lease.Spec.RenewTime.Time).To(BeTemporally(">", first))
+	g.Expect(lease.Spec.AcquireTime.Time).To(BeTemporally("==", first))
+
+	// A sweep that can't list namespaces leaves the lease to go stale
+	second := lease.Spec.RenewTime.Time
+	failList = true
+	s.SweepOnce(ctx)
+	g.Expect(c.Get(ctx, ref, &lease)).To(Succeed())
+	g.Expect(lease.Spec.RenewTime.Time).To(BeTemporally("==", second))
+}
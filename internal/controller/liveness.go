@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var livenessErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "external_liveness_errors_total",
+	Help: "Total failed external liveness checks (the namespace was treated as alive).",
+})
+
+func init() {
+	registerMetrics(livenessErrorsTotal)
+}
+
+// errLivenessUnreachable marks liveness checks that got no response at all, e.g. timed out.
+var errLivenessUnreachable = errors.New("endpoint unreachable")
+
+// LivenessChecker asks an external service whether the resource backing a namespace still exists.
+// It GETs URL, rendered per namespace, and expects a JSON body {"alive": <bool>}. Any error,
+// non-2xx status or malformed body counts as alive, so an outage never deletes anything.
+type LivenessChecker struct {
+	URL *template.Template
+	// Timeout bounds each request; 0 means 5s.
+	Timeout time.Duration
+	// CacheTTL is how long an answer is reused; 0 disables caching.
+	CacheTTL time.Duration
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]livenessAnswer
+}
+
+type livenessAnswer struct {
+	alive   bool
+	expires time.Time
+}
+
+// ParseLivenessURL parses a --external-liveness-url value. The template sees the namespace's
+// .Name, .Labels and .Annotations, e.g. "https://db.internal/alive?ns={{.Name | urlquery}}".
+func ParseLivenessURL(raw string) (*template.Template, error) {
+	t, err := template.New("liveness-url").Option("missingkey=zero").Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("external liveness url %q: %w", raw, err)
+	}
+	return t, nil
+}
+
+// Alive reports whether the external resource linked to ns is still there. The error, if any,
+// explains why the answer defaulted to true.
+func (l *LivenessChecker) Alive(ctx context.Context, ns *corev1.Namespace) (bool, error) {
+	var sb strings.Builder
+	if err := l.URL.Execute(&sb, struct {
+		Name        string
+		Labels      map[string]string
+		Annotations map[string]string
+	}{ns.Name, ns.Labels, ns.Annotations}); err != nil {
+		livenessErrorsTotal.Inc()
+		return true, fmt.Errorf("rendering liveness url: %w", err)
+	}
+	url := sb.String()
+
+	now := time.Now()
+	l.mu.Lock()
+	if a, ok := l.cache[url]; ok && now.Before(a.expires) {
+		l.mu.Unlock()
+		return a.alive, nil
+	}
+	l.mu.Unlock()
+
+	alive, err := l.fetch(ctx, url)
+	if err != nil {
+		livenessErrorsTotal.Inc()
+		return true, err
+	}
+	if l.CacheTTL > 0 {
+		l.mu.Lock()
+		if l.cache == nil {
+			l.cache = map[string]livenessAnswer{}
+		}
+		// Drop stale answers so the cache can't outgrow the live namespaces
+		for k, a := range l.cache {
+			if !now.Before(a.expires) {
+				delete(l.cache, k)
+			}
+		}
+		l.cache[url] = livenessAnswer{alive: alive, expires: now.Add(l.CacheTTL)}
+		l.mu.Unlock()
+	}
+	return alive, nil
+}
+
+func (l *LivenessChecker) fetch(ctx context.Context, url string) (bool, error) {
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("liveness request: %w", err)
+	}
+	c := l.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("liveness request: %w: %w", errLivenessUnreachable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, fmt.Errorf("liveness request: unexpected status %s", resp.Status)
+	}
+	var body struct {
+		Alive *bool `json:"alive"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err != nil {
+		return false, fmt.Errorf("liveness response: %w", err)
+	}
+	if body.Alive == nil {
+		return false, fmt.Errorf("liveness response: missing \"alive\"")
+	}
+	return *body.Alive, nil
+}
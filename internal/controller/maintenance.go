@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// MaintenanceKey is the MaintenanceConfigMap data key that, set to "true", enables maintenance mode.
+const MaintenanceKey = "enabled"
+
+var maintenanceBonus = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "maintenance_bonus_seconds",
+	Help: "TTL bonus added to every namespace in the last sweep, 0 outside maintenance mode.",
+})
+
+func init() {
+	registerMetrics(maintenanceBonus)
+}
+
+// activeMaintenanceBonus returns the TTL bonus for this sweep: MaintenanceBonus while the
+// MaintenanceConfigMap has MaintenanceKey=true, otherwise 0. When the ConfigMap can't be read
+// the bonus is granted, since erring that way only delays deletions.
+func (s *NamespaceSweeper) activeMaintenanceBonus(ctx context.Context, logger logr.Logger) time.Duration {
+	if s.MaintenanceConfigMap.Name == "" || s.MaintenanceBonus <= 0 {
+		maintenanceBonus.Set(0)
+		return 0
+	}
+
+	var bonus time.Duration
+	var cm corev1.ConfigMap
+	err := s.Client.Get(ctx, s.MaintenanceConfigMap, &cm)
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		logger.Error(err, "Failed to read the maintenance ConfigMap, granting the maintenance bonus",
+			"configMap", s.MaintenanceConfigMap.String())
+		bonus = s.MaintenanceBonus
+	case cm.Data[MaintenanceKey] == "true":
+		bonus = s.MaintenanceBonus
+	}
+
+	maintenanceBonus.Set(bonus.Seconds())
+	if bonus > 0 {
+		logger.Info("Maintenance mode active, extending every TTL", "bonus", bonus.String())
+	}
+	return bonus
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultSystemLabelMarkers are labels found on OpenShift platform namespaces and legacy addons.
+var DefaultSystemLabelMarkers = []string{
+	"openshift.io/run-level",
+	"openshift.io/cluster-monitoring=true",
+	"kubernetes.io/cluster-service=true",
+}
+
+// AnnotationMatcher requires the value of annotation Key to match Regexp.
+type AnnotationMatcher struct {
+	Key    string
+	Regexp *regexp.Regexp
+}
+
+// ParseAnnotationMatcher parses a "key=regexp" flag value, e.g. "git-branch=^(feature|bugfix)/".
+func ParseAnnotationMatcher(raw string) (AnnotationMatcher, error) {
+	key, expr, ok := strings.Cut(raw, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return AnnotationMatcher{}, fmt.Errorf("annotation matcher %q: want key=regexp", raw)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return AnnotationMatcher{}, fmt.Errorf("annotation matcher %q: %w", raw, err)
+	}
+	return AnnotationMatcher{Key: key, Regexp: re}, nil
+}
+
+// firstMismatch returns the first matcher the annotations fail. A missing annotation never matches.
+func firstMismatch(annotations map[string]string, matchers []AnnotationMatcher) (AnnotationMatcher, bool) {
+	for _, m := range matchers {
+		val, ok := annotations[m.Key]
+		if !ok || !m.Regexp.MatchString(val) {
+			return m, true
+		}
+	}
+	return AnnotationMatcher{}, false
+}
+
+// EnableLabel returns LabelKey, or LabelPreview when it is unset.
+func (s *NamespaceSweeper) EnableLabel() string {
+	if s.LabelKey == "" {
+		return LabelPreview
+	}
+	return s.LabelKey
+}
+
+// ValidateLabelKey reports whether key is a valid label key, e.g. "example.com/enabled".
+func ValidateLabelKey(key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("label key %q: %s", key, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// prefixes returns Prefixes, or DefaultPrefix when there are none.
+func (s *NamespaceSweeper) prefixes() []string {
+	if len(s.Prefixes) == 0 {
+		return []string{DefaultPrefix}
+	}
+	return s.Prefixes
+}
+
+// hasEnablePrefix reports whether name starts with one of the sweeper's prefixes.
+func (s *NamespaceSweeper) hasEnablePrefix(name string) bool {
+	_, ok := firstPrefix(name, s.prefixes())
+	return ok
+}
+
+// firstPrefix returns the first of prefixes that name starts with.
+func firstPrefix(name string, prefixes []string) (string, bool) {
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// firstMissingAnnotation returns the first required key absent from annotations.
+func firstMissingAnnotation(annotations map[string]string, required []string) (string, bool) {
+	for _, key := range required {
+		if _, ok := annotations[key]; !ok {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// systemMarker reports the first sign that ns is a platform namespace. Markers are label keys
+// ("key", any value) or "key=value" pairs.
+func systemMarker(ns *corev1.Namespace, markers []string) (string, bool) {
+	if name, ok := ns.Labels[corev1.LabelMetadataName]; ok && name != ns.Name {
+		return corev1.LabelMetadataName, true
+	}
+	if len(markers) == 0 {
+		markers = DefaultSystemLabelMarkers
+	}
+	for _, marker := range markers {
+		key, want, hasValue := strings.Cut(marker, "=")
+		val, ok := ns.Labels[key]
+		if ok && (!hasValue || val == want) {
+			return marker, true
+		}
+	}
+	return "", false
+}
+
+// isGitOpsOwned reports whether key is present on ns as a label or an annotation. ArgoCD, for
+// one, tracks ownership with a label or an annotation depending on its configuration.
+func isGitOpsOwned(ns *corev1.Namespace, key string) bool {
+	if key == "" {
+		return false
+	}
+	_, isLabel := ns.Labels[key]
+	_, isAnnotation := ns.Annotations[key]
+	return isLabel || isAnnotation
+}
+
+// sourceClosed reports whether AnnotationSourceState says the namespace's branch or PR is
+// closed or merged, and the normalized state. Any other value, including "open", keeps it.
+func sourceClosed(ns *corev1.Namespace) (string, bool) {
+	state := strings.ToLower(strings.TrimSpace(ns.Annotations[AnnotationSourceState]))
+	return state, state == "closed" || state == "merged"
+}
+
+// expiresAtAnnotation parses AnnotationExpiresAt. set is false when it is missing or blank.
+func expiresAtAnnotation(annotations map[string]string) (expiresAt time.Time, set bool, err error) {
+	raw := strings.TrimSpace(annotations[AnnotationExpiresAt])
+	if raw == "" {
+		return time.Time{}, false, nil
+	}
+	expiresAt, err = time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("%s %q is not an RFC 3339 time", AnnotationExpiresAt, raw)
+	}
+	return expiresAt, true, nil
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// DefaultMetricsNamespace prefixes every metric name unless SetMetricsNamespace changes it.
+const DefaultMetricsNamespace = "preview_sweeper"
+
+var metricsNamespaceRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var (
+	// collectors are the package's metrics, named without the namespace prefix.
+	collectors       []prometheus.Collector
+	metricsNamespace = DefaultMetricsNamespace
+)
+
+// registerMetrics adds cs to the package's metrics and registers them with the controller-runtime
+// registry under the current namespace. Each file registers its own metrics from init().
+func registerMetrics(cs ...prometheus.Collector) {
+	collectors = append(collectors, cs...)
+	metricsRegisterer(metricsNamespace).MustRegister(cs...)
+}
+
+func metricsRegisterer(namespace string) prometheus.Registerer {
+	return prometheus.WrapRegistererWithPrefix(namespace+"_", crmetrics.Registry)
+}
+
+// ValidateMetricsNamespace reports whether namespace can prefix metric names.
+func ValidateMetricsNamespace(namespace string) error {
+	if !metricsNamespaceRegexp.MatchString(namespace) {
+		return fmt.Errorf("metrics namespace %q: want letters, digits and underscores, not starting with a digit", namespace)
+	}
+	return nil
+}
+
+// SetMetricsNamespace moves every metric in the controller-runtime registry, and the series of
+// the decision snapshot, under namespace: "platform_preview_sweeper" turns
+// preview_sweeper_sweeps_total into platform_preview_sweeper_sweeps_total. It must be called at
+// startup, before metrics are served or sweeps run.
+func SetMetricsNamespace(namespace string) error {
+	if err := ValidateMetricsNamespace(namespace); err != nil {
+		return err
+	}
+	if namespace == metricsNamespace {
+		return nil
+	}
+	old, reg := metricsRegisterer(metricsNamespace), metricsRegisterer(namespace)
+	for _, c := range collectors {
+		old.Unregister(c)
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return fmt.Errorf("registering metrics under %q: %w", namespace, err)
+		}
+	}
+	metricsNamespace = namespace
+	return nil
+}
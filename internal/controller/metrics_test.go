@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestSetMetricsNamespace(t *testing.T) {
+	g := NewWithT(t)
+	names := func() []string {
+		families, err := crmetrics.Registry.Gather()
+		g.Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, mf := range families {
+			if strings.Contains(mf.GetName(), "preview_sweeper_") {
+				names = append(names, mf.GetName())
+			}
+		}
+		return names
+	}
+	g.Expect(names()).To(ContainElement("preview_sweeper_sweeps_total"))
+
+	g.Expect(SetMetricsNamespace("platform_preview_sweeper")).To(Succeed())
+	t.Cleanup(func() { g.Expect(SetMetricsNamespace(DefaultMetricsNamespace)).To(Succeed()) })
+	g.Expect(names()).To(ContainElements("platform_preview_sweeper_sweeps_total", "platform_preview_sweeper_seconds_since_last_delete"))
+	g.Expect(names()).To(HaveEach(HavePrefix("platform_preview_sweeper_")))
+
+	// The decision snapshot follows
+	s := &NamespaceSweeper{Client: newFakeClient(nil, previewNS("preview-a", time.Minute, nil)), TTL: time.Hour}
+	rec := httptest.NewRecorder()
+	s.DecisionsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/decisions", nil))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`platform_preview_sweeper_decision{`))
+
+	g.Expect(SetMetricsNamespace("9lives")).To(MatchError(ContainSubstring("metrics namespace")))
+	g.Expect(SetMetricsNamespace("platform-sweeper")).NotTo(Succeed())
+	g.Expect(names()).To(ContainElement("platform_preview_sweeper_sweeps_total"))
+}
@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	notificationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_total",
+		Help: "Total deletion notifications by result.",
+	}, []string{"result"}) // result=sent|error|skipped|dropped
+	notifyBreakerOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "notify_breaker_open",
+		Help: "1 while the notification circuit breaker is open and notifications are skipped.",
+	})
+)
+
+func init() {
+	registerMetrics(notificationsTotal, notifyBreakerOpen)
+}
+
+// errBreakerOpen is returned by Notify while the circuit breaker is open.
+var errBreakerOpen = errors.New("notification circuit breaker open")
+
+// Notification is the JSON body POSTed to the notification webhook just before every deletion,
+// and for every deletion dry-run skipped.
+type Notification struct {
+	Namespace  string `json:"namespace"`
+	Age        string `json:"age"`
+	TTL        string `json:"ttl"`
+	TTLSource  string `json:"ttlSource"`
+	DryRun     bool   `json:"dryRun,omitempty"`
+	Controller string `json:"controller,omitempty"`
+}
+
+// Notifier POSTs deletion notifications to a webhook. Timeouts, network errors and 5xx responses
+// are retried with exponential backoff. After BreakerThreshold notifications in a row failed, the
+// breaker opens and notifications are skipped for BreakerCooldown, so a flaky endpoint can't slow
+// sweeps down; the first one after the cooldown probes the endpoint again. Notifications are
+// queued for a fixed pool of workers, so a burst of deletions can't start unbounded requests.
+type Notifier struct {
+	URL string
+	// Timeout bounds each attempt; 0 means 5s.
+	Timeout time.Duration
+	// Retries is the number of extra attempts after a retryable failure.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each further one; 0 means 500ms.
+	Backoff time.Duration
+	// BreakerThreshold failed notifications in a row open the breaker; 0 disables it.
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open; 0 means 5m.
+	BreakerCooldown time.Duration
+	// Workers is how many notifications are sent at once; 0 means 4.
+	Workers int
+	// QueueSize bounds the notifications waiting for a worker, further ones are dropped; 0 means 100.
+	QueueSize int
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	startOnce sync.Once
+	queue     chan queuedNotification
+
+	mu          sync.Mutex
+	failures    int
+	openedAt    time.Time
+	breakerOpen bool
+	// a notification is probing the endpoint after the cooldown, see allow
+	probing bool
+}
+
+// queuedNotification is a notification waiting for a Notifier worker.
+type queuedNotification struct {
+	ctx    context.Context
+	logger logr.Logger
+	n      Notification
+}
+
+// notify queues n to be sent in the background, so a slow webhook never holds up the sweep;
+// Notifier's timeout, retries and breaker bound how long it takes. The sweep's context ends with
+// the sweep, so n is sent without its cancellation.
+func (s *NamespaceSweeper) notify(ctx context.Context, logger logr.Logger, n Notification) {
+	if s.Notifier == nil {
+		return
+	}
+	if !s.Notifier.enqueue(queuedNotification{ctx: context.WithoutCancel(ctx), logger: logger, n: n}) {
+		notificationsTotal.WithLabelValues("dropped").Inc()
+		logger.Info("Dropping deletion notification, the queue is full", "name", n.Namespace)
+	}
+}
+
+// enqueue hands q to the workers, starting them on first use. It reports false if the queue is full.
+func (nt *Notifier) enqueue(q queuedNotification) bool {
+	nt.startOnce.Do(func() {
+		workers, size := nt.Workers, nt.QueueSize
+		if workers <= 0 {
+			workers = 4
+		}
+		if size <= 0 {
+			size = 100
+		}
+		nt.queue = make(chan queuedNotification, size)
+		for range workers {
+			go nt.work()
+		}
+	})
+	select {
+	case nt.queue <- q:
+		return true
+	default:
+		return false
+	}
+}
+
+// work sends queued notifications until the process exits.
+func (nt *Notifier) work() {
+	for q := range nt.queue {
+		err := nt.Notify(q.ctx, q.n)
+		switch {
+		case errors.Is(err, errBreakerOpen):
+			q.logger.V(1).Info("Skipping deletion notification (circuit breaker open)", "name", q.n.Namespace)
+		case err != nil:
+			q.logger.Error(err, "Failed to send deletion notification", "name", q.n.Namespace)
+		}
+	}
+}
+
+// Notify sends n, retrying as configured. It returns errBreakerOpen without calling the webhook
+// while the breaker is open.
+func (nt *Notifier) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("encoding notification: %w", err)
+	}
+	ok, probe := nt.allow()
+	if !ok {
+		notificationsTotal.WithLabelValues("skipped").Inc()
+		return errBreakerOpen
+	}
+
+	backoff := nt.Backoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	for attempt := 0; ; attempt++ {
+		var retryable bool
+		retryable, err = nt.post(ctx, body)
+		if err == nil || !retryable || attempt >= nt.Retries {
+			break
+		}
+		if werr := sleepCtx(ctx, backoff<<attempt); werr != nil {
+			err = errors.Join(err, werr)
+			break
+		}
+	}
+
+	nt.record(err == nil, probe)
+	if err != nil {
+		notificationsTotal.WithLabelValues("error").Inc()
+		return err
+	}
+	notificationsTotal.WithLabelValues("sent").Inc()
+	return nil
+}
+
+// post makes one attempt and reports whether a failure is worth retrying.
+func (nt *Notifier) post(ctx context.Context, body []byte) (bool, error) {
+	timeout := nt.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, nt.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c := nt.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("notification request: %w", err)
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("notification request: unexpected status %s", resp.Status)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return false, fmt.Errorf("notification request: unexpected status %s", resp.Status)
+	}
+	return false, nil
+}
+
+// allow reports whether the breaker lets a notification through, and whether it is the probe
+// sent after the cooldown.
+func (nt *Notifier) allow() (ok, probe bool) {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+	if nt.BreakerThreshold <= 0 || nt.failures < nt.BreakerThreshold {
+		return true, false
+	}
+	cooldown := nt.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = 5 * time.Minute
+	}
+	// Half-open: let one through after the cooldown, the other workers skip until its outcome is
+	// recorded; a failure re-opens it for another cooldown
+	if nt.probing || time.Since(nt.openedAt) < cooldown {
+		return false, false
+	}
+	nt.probing = true
+	return true, true
+}
+
+// record updates the breaker with the outcome of a notification.
+func (nt *Notifier) record(ok, probe bool) {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+	if probe {
+		nt.probing = false
+	}
+	if ok {
+		nt.failures = 0
+		if nt.breakerOpen {
+			nt.breakerOpen = false
+			notifyBreakerOpen.Set(0)
+		}
+		return
+	}
+	nt.failures++
+	if nt.BreakerThreshold > 0 && nt.failures >= nt.BreakerThreshold {
+		nt.openedAt = time.Now()
+		nt.breakerOpen = true
+		notifyBreakerOpen.Set(1)
+	}
+}
+
+// sleepCtx waits for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
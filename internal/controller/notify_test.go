@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNotifierTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	n := &Notifier{URL: srv.URL, Timeout: 20 * time.Millisecond}
+	start := time.Now()
+	err := n.Notify(context.Background(), Notification{Namespace: "preview-a"})
+	g.Expect(err).To(MatchError(ContainSubstring("deadline exceeded")))
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+}
+
+func TestNotifierRetriesThenSucceeds(t *testing.T) {
+	g := NewWithT(t)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	n := &Notifier{URL: srv.URL, Retries: 2, Backoff: time.Millisecond}
+	g.Expect(n.Notify(context.Background(), Notification{Namespace: "preview-a"})).To(Succeed())
+	g.Expect(calls.Load()).To(Equal(int32(3)))
+
+	// 4xx is not retried
+	calls.Store(0)
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	n = &Notifier{URL: rejecting.URL, Retries: 2, Backoff: time.Millisecond}
+	g.Expect(n.Notify(context.Background(), Notification{Namespace: "preview-a"})).NotTo(Succeed())
+	g.Expect(calls.Load()).To(Equal(int32(1)))
+}
+
+func TestNotifierBreakerOpens(t *testing.T) {
+	g := NewWithT(t)
+
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	n := &Notifier{URL: srv.URL, BreakerThreshold: 2, BreakerCooldown: 50 * time.Millisecond}
+	ctx := context.Background()
+	g.Expect(n.Notify(ctx, Notification{})).NotTo(Succeed())
+	g.Expect(n.Notify(ctx, Notification{})).NotTo(Succeed())
+	g.Expect(testutil.ToFloat64(notifyBreakerOpen)).To(Equal(1.0))
+
+	// Open: the endpoint isn't called at all
+	g.Expect(errors.Is(n.Notify(ctx, Notification{}), errBreakerOpen)).To(BeTrue())
+	g.Expect(calls.Load()).To(Equal(int32(2)))
+
+	// After the cooldown one probe goes through; success closes the breaker
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	g.Expect(n.Notify(ctx, Notification{})).To(Succeed())
+	g.Expect(testutil.ToFloat64(notifyBreakerOpen)).To(BeZero())
+	g.Expect(n.Notify(ctx, Notification{})).To(Succeed())
+}
+
+func TestNotifierBreakerSendsOneProbe(t *testing.T) {
+	g := NewWithT(t)
+
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		<-release
+	}))
+	defer srv.Close()
+
+	n := &Notifier{URL: srv.URL, BreakerThreshold: 1, BreakerCooldown: 50 * time.Millisecond}
+	ctx := context.Background()
+	g.Expect(n.Notify(ctx, Notification{})).NotTo(Succeed())
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	probed := make(chan error)
+	go func() { probed <- n.Notify(ctx, Notification{}) }()
+	g.Eventually(calls.Load).Should(Equal(int32(2)))
+
+	// While the probe hangs, the other workers still skip
+	for range 3 {
+		g.Expect(errors.Is(n.Notify(ctx, Notification{}), errBreakerOpen)).To(BeTrue())
+	}
+	g.Expect(calls.Load()).To(Equal(int32(2)))
+
+	close(release)
+	g.Eventually(probed).Should(Receive(BeNil()))
+	g.Expect(n.Notify(ctx, Notification{})).To(Succeed())
+}
+
+func TestSweepNotifiesWithoutBlocking(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	received := make(chan Notification, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		_ = json.NewDecoder(r.Body).Decode(&n)
+		received <- n
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := newFakeClient(nil,
+		previewNS("preview-real", 2*time.Hour, map[string]string{AnnotationEnforce: "true"}),
+		previewNS("preview-dry", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, DryRun: true, Notifier: &Notifier{URL: srv.URL, Timeout: 5 * time.Second}}
+
+	// Returns while the webhook still hangs
+	g.Expect(s.SweepOnce(ctx).Deleted).To(Equal(1))
+
+	var got []Notification
+	for range 2 {
+		var n Notification
+		g.Eventually(received).Should(Receive(&n))
+		g.Expect(n.Age).NotTo(BeEmpty())
+		n.Age = ""
+		got = append(got, n)
+	}
+	g.Expect(got).To(ConsistOf(
+		Notification{Namespace: "preview-real", TTL: "1h0m0s", TTLSource: "default"},
+		Notification{Namespace: "preview-dry", TTL: "1h0m0s", TTLSource: "default", DryRun: true},
+	))
+}
+
+func TestNotifyOutlivesSweep(t *testing.T) {
+	g := NewWithT(t)
+
+	// Reports whether the sweeper waited for the slow webhook or hung up on it
+	answered := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			answered <- true
+		case <-r.Context().Done():
+			answered <- false
+		}
+	}))
+	defer srv.Close()
+
+	// The sweep's context is cancelled as soon as SweepOnce returns
+	c := newFakeClient(nil, previewNS("preview-old", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, SweepTimeout: time.Minute, Notifier: &Notifier{URL: srv.URL}}
+	s.SweepOnce(context.Background())
+
+	g.Eventually(answered).Should(Receive(BeTrue()))
+}
+
+func TestNotifyQueueIsBounded(t *testing.T) {
+	g := NewWithT(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := &NamespaceSweeper{Notifier: &Notifier{URL: srv.URL, Workers: 1, QueueSize: 2}}
+	dropped := testutil.ToFloat64(notificationsTotal.WithLabelValues("dropped"))
+	for range 10 {
+		s.notify(context.Background(), logr.Discard(), Notification{Namespace: "preview-a"})
+	}
+	// One in flight and two queued at most; which of the first three the worker picked up first
+	// depends on scheduling, so allow either
+	g.Expect(testutil.ToFloat64(notificationsTotal.WithLabelValues("dropped")) - dropped).To(BeNumerically(">=", 7))
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AnnotationPolicy names one of Policies; the namespace gets that policy's TTL instead of the
+// default one. A ttl annotation still wins over it.
+const AnnotationPolicy = "preview-sweeper.maxsauce.com/policy"
+
+// ParsePolicy parses a "name=ttl" flag value, e.g. "long-lived=336h".
+func ParsePolicy(raw string) (string, time.Duration, error) {
+	name, val, ok := strings.Cut(raw, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", 0, fmt.Errorf("policy %q: want name=ttl", raw)
+	}
+	ttl, ok := parseDurationAnnotation(val)
+	if !ok || ttl <= 0 {
+		return "", 0, fmt.Errorf("policy %q: ttl must be a positive duration", raw)
+	}
+	return name, ttl, nil
+}
+
+// resolveTTL is the package resolveTTL, with the TTL of the policy named by AnnotationPolicy in
+// place of the default. The source of a policy TTL is "policy:<name>".
+func (s *NamespaceSweeper) resolveTTL(annotations map[string]string) (time.Duration, string) {
+	ttl, src := resolveTTL(annotations, s.TTL)
+	if src != "default" {
+		return ttl, src
+	}
+	if name := strings.TrimSpace(annotations[AnnotationPolicy]); name != "" {
+		if policyTTL, ok := s.Policies[name]; ok {
+			return policyTTL, "policy:" + name
+		}
+	}
+	return ttl, src
+}
+
+// unknownPolicy returns the policy named by AnnotationPolicy when there is no such policy.
+func (s *NamespaceSweeper) unknownPolicy(annotations map[string]string) (string, bool) {
+	name := strings.TrimSpace(annotations[AnnotationPolicy])
+	if name == "" {
+		return "", false
+	}
+	_, ok := s.Policies[name]
+	return name, !ok
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPolicyAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-long-lived", 2*time.Hour, map[string]string{AnnotationPolicy: "long-lived"}),
+		previewNS("preview-long-lived-old", 30*time.Hour, map[string]string{AnnotationPolicy: "long-lived"}),
+		previewNS("preview-unknown", 2*time.Hour, map[string]string{AnnotationPolicy: "forever"}),
+		previewNS("preview-ttl-wins", 2*time.Hour, map[string]string{AnnotationPolicy: "long-lived", AnnotationTTL: "30m"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Policies: map[string]time.Duration{"long-lived": 24 * time.Hour}}
+
+	ttl, src := s.resolveTTL(map[string]string{AnnotationPolicy: " long-lived "})
+	g.Expect(ttl).To(Equal(24 * time.Hour))
+	g.Expect(src).To(Equal("policy:long-lived"))
+	ttl, src = s.resolveTTL(map[string]string{AnnotationPolicy: "forever"})
+	g.Expect(ttl).To(Equal(time.Hour))
+	g.Expect(src).To(Equal("default"))
+	_, src = s.resolveTTL(map[string]string{AnnotationPolicy: "long-lived", AnnotationTTL: "30m"})
+	g.Expect(src).To(Equal("annotation"))
+
+	report, ok := s.evaluate(ctx, c)
+	g.Expect(ok).To(BeTrue())
+	for _, e := range report.Namespaces {
+		if e.Namespace == "preview-unknown" {
+			g.Expect(e.Problems).To(ConsistOf(ContainSubstring(`unknown ` + AnnotationPolicy + ` "forever"`)))
+		}
+	}
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-long-lived")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-long-lived-old")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-unknown")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-ttl-wins")).To(BeTrue())
+}
+
+func TestParsePolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	name, ttl, err := ParsePolicy("long-lived=336h")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(name).To(Equal("long-lived"))
+	g.Expect(ttl).To(Equal(336 * time.Hour))
+
+	_, ttl, err = ParsePolicy("weekly=168")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ttl).To(Equal(168 * time.Hour))
+
+	for _, raw := range []string{"long-lived", "=1h", "short=0s", "bad=soon"} {
+		_, _, err := ParsePolicy(raw)
+		g.Expect(err).To(HaveOccurred(), raw)
+	}
+}
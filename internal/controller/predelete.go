@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var preDeleteExecTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "pre_delete_exec_total",
+	Help: "Total pre-delete command runs by result.",
+}, []string{"result"}) // result=allowed|vetoed|error
+
+func init() {
+	registerMetrics(preDeleteExecTotal)
+}
+
+// preDeleteOutputLimit caps how much of the command's output is logged.
+const preDeleteOutputLimit = 4 << 10
+
+// PreDeleteExec runs a local command before each deletion. The command gets the namespace name as
+// its only argument and in $PREVIEW_NAMESPACE; exit 0 allows the deletion, any other exit code
+// defers it to a later sweep. It runs without a shell.
+type PreDeleteExec struct {
+	Path string
+	// Timeout bounds each run; 0 means 30s.
+	Timeout time.Duration
+	// FailOpen deletes anyway when the command can't be run or times out. By default such
+	// failures defer the deletion like a non-zero exit.
+	FailOpen bool
+}
+
+// Allow runs the command for ns and reports whether ns may be deleted. output is the command's
+// combined output, trimmed and capped. err is set when the command could not give an answer;
+// the result then follows FailOpen.
+func (h *PreDeleteExec) Allow(ctx context.Context, ns *corev1.Namespace) (allow bool, output string, err error) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Path, ns.Name)
+	cmd.Env = append(os.Environ(), "PREVIEW_NAMESPACE="+ns.Name)
+	// Don't wait on pipes held open by children the command left behind
+	cmd.WaitDelay = time.Second
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	runErr := cmd.Run()
+	output = strings.TrimSpace(out.String())
+	if len(output) > preDeleteOutputLimit {
+		output = output[:preDeleteOutputLimit] + "..."
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case runErr == nil:
+		preDeleteExecTotal.WithLabelValues("allowed").Inc()
+		return true, output, nil
+	case ctx.Err() != nil:
+		err = fmt.Errorf("pre-delete command timed out after %s", timeout)
+	case errors.As(runErr, &exitErr):
+		preDeleteExecTotal.WithLabelValues("vetoed").Inc()
+		return false, output, nil
+	default:
+		err = fmt.Errorf("running pre-delete command: %w", runErr)
+	}
+	preDeleteExecTotal.WithLabelValues("error").Inc()
+	return h.FailOpen, output, err
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// writeScript writes an executable shell script to a temp dir and returns its path.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPreDeleteExec(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	ns := previewNS("preview-a", time.Hour, nil)
+
+	allow, out, err := (&PreDeleteExec{Path: writeScript(t, `echo "ok $1 $PREVIEW_NAMESPACE"`)}).Allow(ctx, ns)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(allow).To(BeTrue())
+	g.Expect(out).To(Equal("ok preview-a preview-a"))
+
+	allow, out, err = (&PreDeleteExec{Path: writeScript(t, "echo busy >&2; exit 3")}).Allow(ctx, ns)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(allow).To(BeFalse())
+	g.Expect(out).To(Equal("busy"))
+
+	// Timeouts and unrunnable commands follow FailOpen; a veto never does
+	slow := writeScript(t, "exec sleep 5")
+	for _, failOpen := range []bool{false, true} {
+		allow, _, err = (&PreDeleteExec{Path: slow, Timeout: 50 * time.Millisecond, FailOpen: failOpen}).Allow(ctx, ns)
+		g.Expect(err).To(MatchError(ContainSubstring("timed out")))
+		g.Expect(allow).To(Equal(failOpen))
+
+		allow, _, err = (&PreDeleteExec{Path: filepath.Join(t.TempDir(), "missing"), FailOpen: failOpen}).Allow(ctx, ns)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(allow).To(Equal(failOpen))
+	}
+}
+
+func TestPreDeleteExecDefersDeletion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-keep", 2*time.Hour, nil),
+		previewNS("preview-go", 2*time.Hour, nil),
+	)
+	hook := writeScript(t, `[ "$1" = preview-go ]`)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, PreDelete: &PreDeleteExec{Path: hook}}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-go")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-keep")).To(BeFalse())
+	g.Expect(res.Deleted).To(Equal(1))
+}
@@ -2,68 +2,162 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var (
 	sweepDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
-		Namespace: "preview_sweeper",
-		Name:      "sweep_seconds",
-		Help:      "Duration of a single sweep pass in seconds.",
-		Buckets:   prometheus.DefBuckets,
+		Name:    "sweep_seconds",
+		Help:    "Duration of a single sweep pass in seconds.",
+		Buckets: prometheus.DefBuckets,
 	})
 	sweepsTotal = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: "preview_sweeper",
-		Name:      "sweeps_total",
-		Help:      "Total number of sweep passes executed.",
+		Name: "sweeps_total",
+		Help: "Total number of sweep passes executed.",
 	})
 	listErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: "preview_sweeper",
-		Name:      "list_errors_total",
-		Help:      "Total number of errors when listing namespaces.",
+		Name: "list_errors_total",
+		Help: "Total number of errors when listing namespaces.",
 	})
 	// Per-sweep gauges (reset each pass)
 	lastScanned = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "preview_sweeper",
-		Name:      "last_sweep_namespaces_scanned",
-		Help:      "Count of namespaces returned by the label selector in the last sweep.",
+		Name: "last_sweep_namespaces_scanned",
+		Help: "Count of namespaces returned by the label selector in the last sweep.",
 	})
 	lastCandidates = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "preview_sweeper",
-		Name:      "last_sweep_candidates",
-		Help:      "Count of namespaces considered (label+prefix) in the last sweep.",
+		Name: "last_sweep_candidates",
+		Help: "Count of namespaces considered (label+prefix) in the last sweep.",
 	})
+	candidatesByTTLSource = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "candidates_by_ttl_source",
+		Help: "Count of namespaces considered in the last sweep, by what sets their TTL.",
+	}, []string{"source"}) // source=default|annotation|sweeppolicy|expires-at
 	lastExpired = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "preview_sweeper",
-		Name:      "last_sweep_expired",
-		Help:      "Count of namespaces older than TTL in the last sweep.",
+		Name: "last_sweep_expired",
+		Help: "Count of namespaces older than TTL in the last sweep.",
 	})
 	lastDeleted = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "preview_sweeper",
-		Name:      "last_sweep_deleted",
-		Help:      "Count of namespaces actually deleted in the last sweep.",
+		Name: "last_sweep_deleted",
+		Help: "Count of namespaces actually deleted in the last sweep.",
+	})
+	lastMissingAnnotation = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "last_sweep_missing_annotation",
+		Help: "Count of namespaces excluded for missing a required annotation in the last sweep.",
 	})
 	deletedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace: "preview_sweeper",
-		Name:      "namespaces_deleted_total",
-		Help:      "Total namespaces deletion outcomes.",
-	}, []string{"result"}) // result=deleted|dry_run|error
+		Name: "namespaces_deleted_total",
+		Help: "Total namespaces deletion outcomes.",
+	}, []string{"result"}) // result=deleted|dry_run|error|already_gone
+	deleteErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "delete_errors_total",
+		Help: "Total failed namespace deletions, by the kind of API error.",
+	}, []string{"reason"}) // reason=forbidden|conflict|timeout|other, see deleteErrorReason
+	lastAnnotationMismatch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "last_sweep_annotation_mismatch",
+		Help: "Count of namespaces excluded by a --match-annotation regexp in the last sweep.",
+	})
+	protectedByAnnotation = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "protected_by_annotation",
+		Help: "Count of namespaces protected by the --protect-annotation, the ignore annotation, a --deny-prefix or the --exclude-selector in the last sweep.",
+	})
+	lastGitOpsOwned = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "last_sweep_gitops_owned",
+		Help: "Count of candidate namespaces skipped as GitOps-owned in the last sweep.",
+	})
+	lastHeld = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "last_sweep_held",
+		Help: "Count of candidate namespaces skipped because of the hold annotation or the hold registry in the last sweep.",
+	})
+	oldestExpiredAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "oldest_expired_age_seconds",
+		Help: "Seconds the most overdue expired candidate was past its effective TTL or expires-at time in the last sweep, 0 if none.",
+	})
+	lastConfirmationRequired = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "last_sweep_confirmation_required",
+		Help: "Count of expired namespaces older than --confirm-threshold kept for lack of a confirm-delete annotation in the last sweep.",
+	})
+	invalidTimestampTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "invalid_creation_timestamp_total",
+		Help: "Total times a namespace was skipped because its creation timestamp was zero or in the future.",
+	}, []string{"reason"}) // reason=zero-timestamp|future-timestamp
+	ttlBelowMinTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ttl_below_min_total",
+		Help: "Total namespaces deleted whose ttl annotation was below --min-ttl and got clamped.",
+	})
+	timeToDeletion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "time_to_deletion_seconds",
+		Help: "Seconds until a namespace expires, only for namespaces within --countdown-window of expiry.",
+	}, []string{"namespace"})
+	ttlChangesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ttl_changes_total",
+		Help: "Total number of times a namespace's effective TTL changed between sweeps.",
+	})
+	killSwitchEngaged = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kill_switch_engaged",
+		Help: "1 if the kill switch file was present in the last sweep (no deletions), 0 otherwise.",
+	})
+	cacheHealthy = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "cache_healthy",
+		Help: "1 if the namespace cache was synced at the start of the last sweep, 0 if deletions were skipped.",
+	})
+	nodePressureFraction = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "node_memory_pressure_fraction",
+		Help: "Fraction of nodes reporting MemoryPressure in the last sweep (with --pressure-aware).",
+	})
+	// unix nanos of the last successful delete, seeded with the process start in init()
+	lastDeleteNanos        atomic.Int64
+	secondsSinceLastDelete = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "seconds_since_last_delete",
+		Help: "Seconds since a namespace was last successfully deleted (or since startup if none was).",
+	}, func() float64 {
+		return time.Since(time.Unix(0, lastDeleteNanos.Load())).Seconds()
+	})
 	lastSweepTS = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "preview_sweeper",
-		Name:      "last_sweep_timestamp_seconds",
-		Help:      "Unix time when a sweep finished.",
+		Name: "last_sweep_timestamp_seconds",
+		Help: "Unix time when a sweep finished.",
+	})
+	lastSuccessTS = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "last_success_timestamp_seconds",
+		Help: "Unix time when a sweep that listed the namespaces without error finished.",
+	})
+	sweepTimeoutsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "sweep_timeouts_total",
+		Help: "Total sweeps abandoned after running longer than --sweep-timeout.",
+	})
+	sweepInProgress = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "sweep_in_progress",
+		Help: "1 while a sweep is running, 0 otherwise.",
 	})
 )
 
@@ -71,16 +165,78 @@ const (
 	LabelPreview   = "preview-sweeper.maxsauce.com/enabled"
 	AnnotationTTL  = "preview-sweeper.maxsauce.com/ttl"
 	AnnotationHold = "preview-sweeper.maxsauce.com/hold"
+	// AnnotationEnforce overrides the global DryRun for one namespace: "true" deletes for real,
+	// "false" keeps it in dry-run.
+	AnnotationEnforce = "preview-sweeper.maxsauce.com/enforce"
+	// AnnotationDryRun set to "true" keeps one namespace in dry-run whatever the global DryRun,
+	// its SweepPolicy or AnnotationEnforce say.
+	AnnotationDryRun = "preview-sweeper.maxsauce.com/dry-run"
+	// AnnotationDeleteNow set to "true" deletes the namespace on the next sweep regardless of age.
+	AnnotationDeleteNow = "preview-sweeper.maxsauce.com/delete-now"
+	// AnnotationDeleteOrder is an integer; with --ordered-delete lower values are deleted first.
+	AnnotationDeleteOrder = "preview-sweeper.maxsauce.com/delete-order"
+	// AnnotationSweepCount is maintained with --track-sweep-count: the number of sweeps that saw the namespace.
+	AnnotationSweepCount = "preview-sweeper.maxsauce.com/sweep-count"
+	// AnnotationSourceState is pushed by an external sync: "open", "closed" or "merged". The
+	// latter two make the namespace eligible for deletion regardless of age.
+	AnnotationSourceState = "preview-sweeper.maxsauce.com/source-state"
+	// AnnotationGrace overrides GracePeriod for one namespace, capped at MaxGracePeriod.
+	AnnotationGrace = "preview-sweeper.maxsauce.com/grace"
+	// AnnotationExpiresAt is an RFC 3339 time after which the namespace is deleted, instead of
+	// its TTL running out.
+	AnnotationExpiresAt = "preview-sweeper.maxsauce.com/expires-at"
+	// AnnotationIgnore set to "true" opts a namespace out of sweeping for good, unlike the
+	// temporary AnnotationHold.
+	AnnotationIgnore = "preview-sweeper.maxsauce.com/ignore"
+	// AnnotationConfirmDelete set to "true" lets a namespace older than ConfirmThreshold be deleted.
+	AnnotationConfirmDelete = "preview-sweeper.maxsauce.com/confirm-delete"
+
+	// DefaultPrefix is the name prefix of namespaces to sweep unless Prefixes is set.
+	DefaultPrefix = "preview-"
+
+	// DefaultFieldManager is the field manager of the sweeper's writes unless FieldManager is set.
+	DefaultFieldManager = "preview-sweeper"
 )
 
 func init() {
-	crmetrics.Registry.MustRegister(
+	// so seconds_since_last_delete isn't misleadingly huge before the first deletion
+	lastDeleteNanos.Store(time.Now().UnixNano())
+
+	registerMetrics(
 		sweepDuration, sweepsTotal, listErrorsTotal,
-		lastScanned, lastCandidates, lastExpired, lastDeleted,
-		deletedTotal, lastSweepTS,
+		lastScanned, lastCandidates, candidatesByTTLSource, lastExpired, lastDeleted,
+		lastMissingAnnotation, lastAnnotationMismatch, protectedByAnnotation, lastGitOpsOwned, lastConfirmationRequired,
+		lastHeld, oldestExpiredAge,
+		deletedTotal, deleteErrorsTotal, lastSweepTS, lastSuccessTS, ttlChangesTotal, killSwitchEngaged,
+		nodePressureFraction, cacheHealthy, secondsSinceLastDelete, timeToDeletion,
+		ttlBelowMinTotal, sweepInProgress, sweepTimeoutsTotal, invalidTimestampTotal,
 	)
 }
 
+// expiredNamespace is a namespace past its TTL, waiting for the delete phase of a sweep.
+type expiredNamespace struct {
+	ns        *corev1.Namespace
+	age       time.Duration
+	ttl       time.Duration
+	ttlSource string
+	// requestedTTL is the annotation TTL before it was raised to MinTTL, 0 if not clamped
+	requestedTTL time.Duration
+	// settleLeft is how much longer the namespace must stay unchanged, see SettlePeriod
+	settleLeft time.Duration
+	// policyDryRun is the dryRun of the namespace's SweepPolicy
+	policyDryRun bool
+	// reason says why the namespace is deleted when that isn't its age exceeding ttl
+	reason string
+}
+
+// SweepResult summarizes one SweepOnce pass.
+type SweepResult struct {
+	Scanned    int `json:"scanned"`
+	Candidates int `json:"candidates"`
+	Expired    int `json:"expired"`
+	Deleted    int `json:"deleted"`
+}
+
 type NamespaceSweeper struct {
 	Client   client.Client
 	TTL      time.Duration
@@ -90,6 +246,304 @@ type NamespaceSweeper struct {
 	JitterPercent float64 // optional: e.g., 0.05 = +-5% jitter; 0 disables it.
 
 	DryRun bool
+
+	// ControllerID identifies this replica (pod name) in deletion events and logs.
+	ControllerID string
+
+	// LivenessLease, when set, is a Lease whose renewTime each sweep that could list namespaces
+	// advances, so external monitoring can spot a stuck sweeper by a stale Lease.
+	LivenessLease types.NamespacedName
+
+	// SweepGuard, when set, coordinates sweeps between replicas running without leader election:
+	// a replica stamps this ConfigMap while it sweeps and the others skip sweeps while the stamp
+	// is younger than SweepGuardTTL. See acquireSweepGuard.
+	SweepGuard    types.NamespacedName
+	SweepGuardTTL time.Duration
+
+	// DeletionLog, when set, names a ConfigMap that gets a DeletionRecord for every namespace
+	// deleted, or that dry-run would have deleted, after each sweep; it is created if missing and
+	// keeps the DeletionLogMaxEntries (0 means DefaultDeletionLogMaxEntries) most recent records.
+	DeletionLog           types.NamespacedName
+	DeletionLogMaxEntries int
+
+	// FieldManager attributes every write to this field manager; empty means DefaultFieldManager.
+	FieldManager string
+
+	// Tracer, when set, starts a span per sweep and per namespace delete; the sweep's trace ID is
+	// then attached as an exemplar to sweep_seconds.
+	Tracer trace.Tracer
+
+	// CacheHealthy reports whether the informer cache behind Client is synced and still
+	// watching, see WatchHealth. When it returns false the sweep still runs but deletes nothing,
+	// as the List may be stale or partial.
+	CacheHealthy func(ctx context.Context) bool
+
+	// IsLeader reports whether this replica holds the leader election lease, so only the leader
+	// sweeps on demand (see TriggerHandler). Nil means it always does, as without leader election.
+	IsLeader func() bool
+
+	// CacheSyncTimeout bounds how long Start waits for CacheHealthy before the first sweep; 0
+	// means 2m.
+	CacheSyncTimeout time.Duration
+
+	// ListTimeout bounds the namespace List so a lost apiserver connection can't hang a sweep.
+	// 0 means no timeout.
+	ListTimeout time.Duration
+
+	// SweepTimeout bounds a whole SweepOnce, so a wedged API server can't stall sweeps for good:
+	// a sweep still running then is abandoned and the next one is scheduled as usual. 0 means no
+	// timeout.
+	SweepTimeout time.Duration
+
+	// MaintenanceBonus is added to every positive TTL while MaintenanceConfigMap has
+	// MaintenanceKey=true, e.g. to give all previews more time during a release freeze.
+	MaintenanceBonus     time.Duration
+	MaintenanceConfigMap types.NamespacedName
+
+	// HoldRegistry names a ConfigMap whose keys are held namespace names and values the reasons.
+	// It is read once per sweep and works alongside the hold annotation. Zero disables it.
+	HoldRegistry types.NamespacedName
+
+	// LabelKey is the label that enables sweeping when "true"; empty means LabelPreview.
+	LabelKey string
+
+	// Prefixes are the name prefixes of namespaces to sweep; empty means DefaultPrefix.
+	Prefixes []string
+
+	// DenyPrefixes protects namespaces whose name starts with any of these, even when they
+	// also match the enable prefix (e.g. "preview-prod-").
+	DenyPrefixes []string
+
+	// ExcludeSelector protects namespaces whose labels match it, even though they carry the
+	// enable label (e.g. "protected=true"). Nil disables it.
+	ExcludeSelector labels.Selector
+
+	// SkipGitOpsOwned leaves namespaces carrying GitOpsOwnershipKey (as a label or annotation)
+	// alone unless they are annotated enforce=true, so the sweeper doesn't fight e.g. ArgoCD.
+	SkipGitOpsOwned    bool
+	GitOpsOwnershipKey string
+
+	// EmptyTTLNever reads a ttl annotation that is present but blank as "never delete" instead of
+	// "use the default TTL".
+	EmptyTTLNever bool
+
+	// Policies are named TTLs a namespace can opt into with AnnotationPolicy, e.g. "long-lived".
+	Policies map[string]time.Duration
+
+	// AgeFromOldestWorkload counts a namespace's age for its TTL from its oldest Pod or Deployment,
+	// falling back to its creation when it has none. Deletion requests by annotation, source state
+	// or liveness ignore age.
+	AgeFromOldestWorkload bool
+
+	// SweepPolicies picks and configures candidates by the cluster's SweepPolicy objects whenever
+	// at least one valid policy exists, instead of the enable label, Prefixes and TTL. Without
+	// the CRD, or without valid policies, the flag configuration applies.
+	SweepPolicies bool
+
+	// IdleTTL expires a namespace that has had no Pods for longer than this, on top of its TTL, so
+	// torn-down previews go early. It lists Pods per candidate each sweep. 0 disables it.
+	IdleTTL time.Duration
+
+	// MinTTL raises positive ttl annotations below it to MinTTL, so a typo'd "1m" can't wipe a
+	// fresh preview. 0 disables the clamp.
+	MinTTL time.Duration
+
+	// StampOnDelete patches LabelDeletedBy and AnnotationDeletedAt onto each namespace right before
+	// deleting it, one extra API call per deletion. A failed stamp doesn't stop the deletion.
+	StampOnDelete bool
+
+	// MinAge is a floor under every deletion: no namespace younger than this (since creation) is
+	// deleted, whatever its TTL, expires-at, delete-now or source-state says. 0 disables it.
+	MinAge time.Duration
+
+	// StaleFactor, between 0 and 1 exclusive, multiplies the TTL of namespaces nobody has written
+	// to since creation, see staleTTL. 0 disables it.
+	StaleFactor float64
+
+	// GracePeriod keeps a namespace for this long after its TTL ran out, emitting a
+	// DeletionPending warning, before deleting it. 0 deletes right away. AnnotationGrace overrides
+	// it per namespace, up to MaxGracePeriod (0 = no cap). Only TTL expiry gets a grace period:
+	// delete-now, source-state, idle and liveness deletions are explicit signals and delete right away.
+	GracePeriod    time.Duration
+	MaxGracePeriod time.Duration
+
+	// ScaleToZero turns the grace period into a soft TTL: when a namespace enters it, its
+	// Deployments and StatefulSets are scaled to zero replicas and it is stamped
+	// AnnotationScaledToZero; it is deleted once the grace period is over. Skipped in dry-run and
+	// while deletions are blocked.
+	ScaleToZero bool
+
+	// DeleteSpread spreads a sweep's deletions over up to this long, with a jittered pause after
+	// each, instead of firing them back to back. The window shrinks so it ends before the next
+	// sweep is due. 0 disables it.
+	DeleteSpread time.Duration
+
+	// ConfirmThreshold keeps expired namespaces older than this, which may have survived on purpose,
+	// until they are annotated AnnotationConfirmDelete=true. Only TTL expiry needs confirming, not
+	// delete-now, source-state or the liveness check. 0 disables it.
+	ConfirmThreshold time.Duration
+
+	// SettlePeriod defers deleting a namespace until its resourceVersion has been stable for this
+	// long, so metadata that CI flips around expiry isn't acted on mid-flap. The sweeper's own
+	// writes don't count. 0 disables it.
+	SettlePeriod time.Duration
+
+	// MetricLabelMaxLen bounds namespace names used as metric label values, see NormalizeLabelValue.
+	MetricLabelMaxLen int
+
+	// TrackSweepCount keeps AnnotationSweepCount up to date on every candidate, at the cost of
+	// one patch per candidate per sweep. Skipped in dry-run.
+	TrackSweepCount bool
+
+	// CountdownWindow, when positive, exports time_to_deletion_seconds for namespaces expiring
+	// within that window. Series are dropped once a namespace leaves the window or is deleted.
+	CountdownWindow time.Duration
+
+	// OrderedDelete deletes each sweep's expired namespaces by ascending AnnotationDeleteOrder,
+	// those without a valid order last, so dependencies can go in the right sequence.
+	OrderedDelete bool
+
+	// ShadowSelector, when set, is evaluated as an alternative to the Prefixes.
+	// Differences from the active decisions are logged and exported; it never deletes anything.
+	ShadowSelector *regexp.Regexp
+
+	// ClockSkew is added to the local clock when computing namespace ages, to correct for a
+	// controller clock that is behind (positive) or ahead of (negative) the apiserver's.
+	ClockSkew time.Duration
+
+	// AuditOnly runs the whole decision pipeline but never deletes, patches or emits events.
+	// Each sweep writes every namespace's disposition as a JSON AuditReport to AuditOutput
+	// ("-" or "" for stdout).
+	AuditOnly   bool
+	AuditOutput string
+	// auditSink, when set, makes sweeps out-of-band evaluations, see evaluate. It receives the
+	// audit report instead of AuditOutput.
+	auditSink func(AuditReport)
+
+	// EventMessageTemplate, when set, renders the message of NamespaceCleanup and
+	// NamespaceCleanupDryRun events from EventMessageData. The reasons stay fixed.
+	EventMessageTemplate *template.Template
+
+	// Notifier, when set, is told just before every namespace deletion and about every dry-run one.
+	Notifier *Notifier
+
+	// Reporter, when set, gets a summary of every sweep that listed namespaces. Not used in
+	// audit-only mode.
+	Reporter *SweepReporter
+
+	// Liveness, when set, makes a namespace eligible for deletion regardless of TTL once the
+	// external resource it is linked to is reported gone. Hold and protection still apply.
+	Liveness *LivenessChecker
+
+	// PreDelete, when set, runs before each deletion and can defer it. Skipped in dry-run.
+	PreDelete *PreDeleteExec
+
+	// DeleteNowOverridesHold lets the delete-now annotation win over hold. The protect annotation
+	// always wins.
+	DeleteNowOverridesHold bool
+
+	// DeletePercentOfExpired deletes only the oldest N% (rounded up) of the expired namespaces
+	// each sweep, so a backlog drains over several sweeps. 0 or >= 100 deletes them all.
+	DeletePercentOfExpired float64
+
+	// PropagationPolicy is sent with every namespace deletion; empty leaves it to the API server.
+	PropagationPolicy metav1.DeletionPropagation
+
+	// DeleteRetryBackoff is the wait before retrying a delete that failed transiently, doubled for
+	// the next retry; 0 means 200ms. See deleteWithRetry.
+	DeleteRetryBackoff time.Duration
+
+	// MaxDeletesPerSweep stops a sweep once it has deleted this many namespaces; the rest wait for
+	// the next sweep, oldest first. Namespaces not deleted, e.g. deferred or in dry-run, don't
+	// count. 0 disables it.
+	MaxDeletesPerSweep int
+
+	// Concurrency deletes up to this many expired namespaces at once; 0 or 1 deletes them one by
+	// one. Ignored while DeleteSpread paces deletions. See deleteConcurrently.
+	Concurrency int
+
+	// ProtectAnnotation names an annotation that, set to "true", makes a namespace permanently
+	// ineligible for deletion. It is checked before any other rule. Empty disables it.
+	ProtectAnnotation string
+
+	// APIReader, when set, re-reads each namespace from the API server (bypassing the cache) right
+	// before deleting it. A namespace whose resourceVersion moved since the cached List (e.g. a hold
+	// was just added) is left for the next sweep, and the delete carries UID/resourceVersion
+	// preconditions. Costs one extra GET per deletion; List stays cached.
+	APIReader client.Reader
+
+	// KillSwitchFile stops all deletions while a file exists at this path (e.g. a ConfigMap key
+	// mounted as a volume). Checked every sweep.
+	KillSwitchFile string
+
+	// PressureAware pauses deletions while at least PressureNodeFraction of nodes report
+	// MemoryPressure, so a sweep doesn't add a burst of pod terminations to a stressed cluster.
+	// Needs nodes list/watch RBAC.
+	PressureAware        bool
+	PressureNodeFraction float64
+
+	// CreatedAfter/CreatedBefore restrict candidates to namespaces created inside the window,
+	// independent of TTL. Zero values leave that side unbounded.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// MatchAnnotations must all match for a namespace to be a candidate.
+	MatchAnnotations []AnnotationMatcher
+
+	// BlockOnPendingLB defers deletion while a LoadBalancer Service in the namespace is still
+	// provisioning or deprovisioning, so the cloud LB isn't leaked. Needs services list/watch RBAC.
+	BlockOnPendingLB bool
+
+	// DetectSystemNamespaces skips namespaces that look like platform namespaces on non-vanilla
+	// distros: a kubernetes.io/metadata.name label that disagrees with the name, or any of
+	// SystemLabelMarkers (DefaultSystemLabelMarkers when empty).
+	DetectSystemNamespaces bool
+	SystemLabelMarkers     []string
+
+	// SystemNamespaces are skipped like BuiltinSystemNamespaces, see isSystemNamespace.
+	SystemNamespaces SystemNamespaceConfig
+
+	// RequireAnnotations lists annotation keys a namespace must all carry (any value)
+	// to be a candidate.
+	RequireAnnotations []string
+
+	// MaxConsecutiveListErrors makes Start return an error once this many sweeps
+	// in a row failed to list namespaces. 0 = never give up.
+	MaxConsecutiveListErrors int
+
+	consecutiveListErrors int
+	// the client rejected the status.phase field selector, see listNamespaces
+	phaseSelectorUnsupported bool
+	// set once a sweep listed the namespaces, see ReadyCheck
+	lastSweepSucceeded atomic.Bool
+	// the last sweep was skipped because another replica holds the sweep guard, see ReadyCheck
+	guardHeldElsewhere atomic.Bool
+	// effective TTL per namespace UID as of the previous sweep
+	lastTTLs map[types.UID]time.Duration
+	// resolveTTL results by namespace UID, see cachedResolveTTL
+	ttlCache    map[types.UID]*resolvedTTL
+	ttlCacheGen uint64
+	// when each candidate's metadata last changed, see SettlePeriod
+	lastChanges map[types.UID]settleState
+	// label values currently exported in time_to_deletion_seconds
+	countdown map[string]struct{}
+	// when each candidate last had Pods, by namespace UID, see idleFor
+	lastBusy map[types.UID]time.Time
+	// namespaces that got their NamespaceCleanupHeld event, see heldEventf
+	heldEvented map[types.UID]struct{}
+
+	// set while evaluating ShadowSelector in place of the Prefixes, see shadowDecisions
+	shadowing bool
+
+	// serializes sweeps with out-of-band evaluations, see evaluate
+	sweepMu sync.Mutex
+
+	jitterMu   sync.Mutex
+	jitterRand *rand.Rand
+
+	// deletion outcomes over the process lifetime, printed on shutdown
+	summaryMu sync.Mutex
+	summary   map[summaryKey]int
 }
 
 // Ensure NamespaceSweeper respects leader election.
@@ -106,9 +560,28 @@ func (s *NamespaceSweeper) Start(ctx context.Context) error {
 		s.Interval = 24 * time.Hour
 	}
 
+	defer s.writeSummary(os.Stdout)
+	// Start only runs while leading; once it returns another replica reports, so don't leave
+	// this one's last values looking live
+	defer s.resetSweepGauges()
+
+	// Start only runs on the leader, but the cache may still be syncing right after election.
+	// Don't schedule the first sweep off an empty or partial cache.
+	if !s.waitForCache(ctx) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		logger.Error(nil, "Namespace cache did not sync in time, sweeps will skip deletions until it does",
+			"timeout", s.cacheSyncTimeout())
+	}
+
 	firstDelay := s.withJitter(s.Interval, 0.1)
 	timer := time.NewTimer(firstDelay)
 	defer timer.Stop()
+	// Sweeps are scheduled on anchor + k*Interval so long sweeps don't push the cadence later
+	anchor := time.Now().Add(firstDelay)
+	// the slot the timer was last set for, before jitter
+	slot := anchor
 
 	logger.Info("Namespace sweeper started",
 		"interval", s.Interval,
@@ -123,27 +596,87 @@ func (s *NamespaceSweeper) Start(ctx context.Context) error {
 			logger.Info("Namespace sweeper stopped")
 			return nil
 		case <-timer.C:
+			// Both cases may be ready at once; don't start a sweep that shutdown would cut short
+			if ctx.Err() != nil {
+				logger.Info("Namespace sweeper stopped")
+				return nil
+			}
 			s.SweepOnce(ctx)
-			next := s.withJitter(s.Interval, s.JitterPercent)
-			timer.Reset(next)
+			if s.MaxConsecutiveListErrors > 0 && s.consecutiveListErrors >= s.MaxConsecutiveListErrors {
+				err := fmt.Errorf("listing namespaces failed %d times in a row", s.consecutiveListErrors)
+				logger.Error(err, "List error budget exhausted, giving up so the pod restarts",
+					"maxConsecutiveListErrors", s.MaxConsecutiveListErrors)
+				return err
+			}
+			var fireAt time.Time
+			slot, fireAt = s.nextSweep(anchor, slot, time.Now())
+			timer.Reset(max(time.Until(fireAt), 0))
 		}
 	}
 }
 
-func (s *NamespaceSweeper) SweepOnce(ctx context.Context) {
+// SweepOnce runs one sweep. Calls from the timer, /sweep and tests don't overlap: a call made
+// while a sweep is running waits for it to finish, then sweeps again.
+func (s *NamespaceSweeper) SweepOnce(ctx context.Context) SweepResult {
+	s.sweepMu.Lock()
+	defer s.sweepMu.Unlock()
+	if s.SweepTimeout <= 0 {
+		return s.sweep(ctx)
+	}
+
+	sweepCtx, cancel := context.WithTimeout(ctx, s.SweepTimeout)
+	defer cancel()
+	res := s.sweep(sweepCtx)
+	if errors.Is(sweepCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		sweepTimeoutsTotal.Inc()
+		log.FromContext(ctx).WithName("NamespaceSweeper").Error(sweepCtx.Err(), "Sweep aborted, it ran longer than --sweep-timeout",
+			"sweepTimeout", s.SweepTimeout.String(), "scanned", res.Scanned, "expired", res.Expired, "deleted", res.Deleted)
+	}
+	return res
+}
+
+// sweep is SweepOnce; callers hold sweepMu.
+func (s *NamespaceSweeper) sweep(ctx context.Context) SweepResult {
 	logger := log.FromContext(ctx).WithName("NamespaceSweeper")
+	// An out-of-band evaluation leaves metrics and the state carried between sweeps alone
+	evaluating := s.auditSink != nil
+	ctx, span := s.tracer().Start(ctx, "Sweep")
+	defer span.End()
+	if s.SweepGuard.Name != "" {
+		release, ok := s.acquireSweepGuard(ctx, logger)
+		if !ok {
+			return SweepResult{}
+		}
+		defer release()
+	}
 	start := time.Now()
-	scanned := 0 // <-- add this
+	scanned := 0
+	if !evaluating {
+		sweepInProgress.Set(1)
+	}
 
 	var (
-		candidates int
-		expired    int
-		deleted    int
+		candidates        int
+		bySource          = map[string]int{}
+		expired           int
+		deleted           int
+		missingAnnotation int
+		mismatch          int
+		protected         int
+		gitOpsOwned       int
+		needConfirmation  int
+		held              int
+		// how far the most overdue expired namespace is past its TTL
+		oldestOverdue time.Duration
 	)
 	// end-of-function metric updates
 	defer func() {
+		if evaluating {
+			return
+		}
+		sweepInProgress.Set(0)
 		sweepsTotal.Inc()
-		sweepDuration.Observe(time.Since(start).Seconds())
+		observeWithTraceExemplar(ctx, sweepDuration, time.Since(start).Seconds())
 		logger.Info("Sweep finished",
 			"scanned", scanned,
 			"candidates", candidates,
@@ -154,117 +687,1262 @@ func (s *NamespaceSweeper) SweepOnce(ctx context.Context) {
 		lastSweepTS.Set(float64(time.Now().Unix()))
 	}()
 
-	sel := labels.SelectorFromSet(labels.Set{LabelPreview: "true"})
-	listOpts := &client.ListOptions{LabelSelector: sel}
+	// Sweep-wide safety checks; when one trips, namespaces are still evaluated but not deleted
+	blocked := s.deletionBlocker(ctx, logger)
+
+	// Read once per sweep; without it holds are unknown, so nothing may be deleted
+	registryHolds, err := s.loadHoldRegistry(ctx)
+	if err != nil {
+		logger.Error(err, "Failed to read the hold registry, refusing to delete", "configMap", s.HoldRegistry.String())
+		if blocked != "" {
+			blocked += ", "
+		}
+		blocked += "hold registry unavailable"
+	}
+
+	bonus := s.activeMaintenanceBonus(ctx, logger)
+
+	// Without knowing the policies the wrong rules could apply, so nothing may be deleted
+	policies, err := s.loadSweepPolicies(ctx, logger)
+	if err != nil {
+		logger.Error(err, "Failed to list SweepPolicies, refusing to delete")
+		if blocked != "" {
+			blocked += ", "
+		}
+		blocked += "sweep policies unavailable"
+	}
+
+	sel := labels.SelectorFromSet(labels.Set{s.EnableLabel(): "true"})
+	if len(policies) > 0 {
+		// Policies carry their own selectors
+		sel = labels.Everything()
+	}
+
+	listCtx := ctx
+	if s.ListTimeout > 0 {
+		var cancel context.CancelFunc
+		listCtx, cancel = context.WithTimeout(ctx, s.ListTimeout)
+		defer cancel()
+	}
 
 	var nsList corev1.NamespaceList
-	if err := s.Client.List(ctx, &nsList, listOpts); err != nil {
+	if err := s.listNamespaces(listCtx, logger, &nsList, sel); err != nil {
+		if shuttingDown(ctx) {
+			logger.Info("Sweep interrupted by shutdown before namespaces were listed")
+			return SweepResult{}
+		}
+		if evaluating {
+			logger.Error(err, "Failed to list namespaces")
+			return SweepResult{}
+		}
 		listErrorsTotal.Inc()
-		logger.Error(err, "Failed to list namespaces")
-		lastScanned.Set(0)
-		lastCandidates.Set(0)
-		lastExpired.Set(0)
-		lastDeleted.Set(0)
-		return
+		s.consecutiveListErrors++
+		logger.Error(err, "Failed to list namespaces", "consecutiveErrors", s.consecutiveListErrors)
+		s.resetSweepGauges()
+		return SweepResult{}
+	}
+	scanned = len(nsList.Items)
+	if !evaluating {
+		s.consecutiveListErrors = 0
+		s.lastSweepSucceeded.Store(true)
+		lastScanned.Set(float64(scanned))
+		defer func() { lastSuccessTS.Set(float64(time.Now().Unix())) }()
 	}
-	lastScanned.Set(float64(len(nsList.Items)))
 
-	now := time.Now()
+	// Ages are measured against the apiserver's clock, which set the creation timestamps
+	now := time.Now().Add(s.ClockSkew)
+	var toDelete []expiredNamespace
+	seenTTLs := make(map[types.UID]time.Duration, len(nsList.Items))
+	countdown := map[string]struct{}{}
+	changes := map[types.UID]settleState{}
+	busy := map[types.UID]time.Time{}
+	heldByAnnotation := map[types.UID]struct{}{}
+	// set once the external liveness endpoint timed out or refused a connection, see below
+	livenessUnreachable := false
+	if !evaluating {
+		defer func() { s.lastTTLs = seenTTLs }()
+		if s.IdleTTL > 0 {
+			defer func() { s.lastBusy = busy }()
+		}
+		defer s.pruneTTLCache()
+		defer s.pruneHeldEvented(heldByAnnotation)
+		defer s.updateCountdown(countdown)
+	}
+
+	// With AuditOnly every namespace's disposition, and the steps that led to it, is collected into a report
+	var audit []AuditEntry
+	var steps []string
+	step := func(format string, args ...any) {
+		if s.AuditOnly {
+			steps = append(steps, fmt.Sprintf(format, args...))
+		}
+	}
+	note := func(ns *corev1.Namespace, d Disposition, reason string) *AuditEntry {
+		if !s.AuditOnly {
+			return &AuditEntry{}
+		}
+		audit = append(audit, AuditEntry{Namespace: ns.Name, Disposition: d, Reason: reason, Steps: steps})
+		return &audit[len(audit)-1]
+	}
 
 	for i := range nsList.Items {
 		ns := &nsList.Items[i]
+		steps = nil
 		if ns.DeletionTimestamp != nil {
+			note(ns, DispositionExcluded, "terminating")
+			continue
+		}
+
+		if reason, ok := s.systemNamespace(ns); ok {
+			logger.V(1).Info("Skipping namespace (system namespace)", "name", ns.Name, "reason", reason)
+			note(ns, DispositionExcluded, reason)
 			continue
 		}
 
-		if ns.Name == "kube-system" || ns.Name == "default" || ns.Name == "kube-public" {
+		var policy *sweepPolicy
+		if len(policies) > 0 {
+			p, ok := matchSweepPolicy(policies, ns)
+			if !ok {
+				note(ns, DispositionExcluded, "matches no SweepPolicy")
+				continue
+			}
+			policy = p
+			step("matches SweepPolicy %s", policy.name)
+		} else if s.shadowing {
+			if !s.ShadowSelector.MatchString(ns.Name) {
+				note(ns, DispositionExcluded, "name doesn't match --shadow-selector")
+				continue
+			}
+		} else if !s.hasEnablePrefix(ns.Name) {
+			note(ns, DispositionExcluded, "name lacks the "+strings.Join(s.prefixes(), " or ")+" prefix")
+			continue
+		}
+		policyDryRun := policy != nil && policy.dryRun
+
+		// Denied prefixes win over the enable prefix and are never candidates
+		if prefix, ok := firstPrefix(ns.Name, s.DenyPrefixes); ok {
+			protected++
+			logger.V(1).Info("Skipping namespace (denied prefix)", "name", ns.Name, "prefix", prefix)
+			note(ns, DispositionProtected, "denied prefix "+prefix)
+			continue
+		}
+
+		if s.ExcludeSelector != nil && s.ExcludeSelector.Matches(labels.Set(ns.Labels)) {
+			protected++
+			logger.V(1).Info("Skipping namespace (matches exclude selector)", "name", ns.Name, "selector", s.ExcludeSelector.String())
+			note(ns, DispositionProtected, "matches exclude selector "+s.ExcludeSelector.String())
+			continue
+		}
+
+		if key, ok := firstMissingAnnotation(ns.Annotations, s.RequireAnnotations); ok {
+			missingAnnotation++
+			logger.V(1).Info("Skipping namespace (missing required annotation)", "name", ns.Name, "annotation", key)
+			note(ns, DispositionExcluded, "missing required annotation "+key)
+			continue
+		}
+
+		// No age can be computed from these; a zero one would look ancient, a future one never expire
+		if reason, ok := invalidCreationTimestamp(ns, now); ok {
+			if !evaluating {
+				invalidTimestampTotal.WithLabelValues(reason).Inc()
+			}
+			logger.Info("Skipping namespace (invalid creation timestamp)", "name", ns.Name, "reason", reason,
+				"created", ns.CreationTimestamp.Time, "now", now)
+			note(ns, DispositionMisconfigured, reason)
 			continue
 		}
 
-		if !strings.HasPrefix(ns.Name, "preview-") {
+		if !s.createdInWindow(ns.CreationTimestamp.Time) {
+			logger.V(1).Info("Skipping namespace (created outside --created-after/--created-before)", "name", ns.Name, "created", ns.CreationTimestamp.Time)
+			note(ns, DispositionExcluded, "created outside --created-after/--created-before")
+			continue
+		}
+
+		if m, ok := firstMismatch(ns.Annotations, s.MatchAnnotations); ok {
+			mismatch++
+			logger.V(1).Info("Skipping namespace (annotation does not match)", "name", ns.Name, "annotation", m.Key, "regexp", m.Regexp.String())
+			note(ns, DispositionExcluded, "annotation "+m.Key+" does not match "+m.Regexp.String())
 			continue
 		}
 
 		candidates++
+		bySource[s.candidateTTLSource(ns.Annotations, policy)]++
+		// Before bumpSweepCount, whose write must not count as a change
+		if s.SettlePeriod > 0 {
+			changes[ns.UID] = s.trackChange(ns, now)
+		}
+
+		if s.TrackSweepCount && !s.DryRun && !s.AuditOnly {
+			if err := s.bumpSweepCount(ctx, ns); err != nil {
+				logger.Error(err, "Failed to update sweep count", "name", ns.Name)
+			}
+		}
+
+		// Break-glass protection beats every other rule
+		if s.ProtectAnnotation != "" && ns.Annotations[s.ProtectAnnotation] == "true" {
+			protected++
+			logger.V(1).Info("Skipping namespace (protected by annotation)", "name", ns.Name, "annotation", s.ProtectAnnotation)
+			note(ns, DispositionProtected, "annotation "+s.ProtectAnnotation)
+			continue
+		}
+		if key, ok := ignoredBy(ns, policy); ok {
+			protected++
+			logger.V(1).Info("Skipping namespace (ignored)", "name", ns.Name, "annotation", key)
+			note(ns, DispositionProtected, "ignored ("+key+")")
+			continue
+		}
+
+		if s.SkipGitOpsOwned && isGitOpsOwned(ns, s.GitOpsOwnershipKey) && ns.Annotations[AnnotationEnforce] != "true" {
+			gitOpsOwned++
+			logger.V(1).Info("Skipping namespace (owned by GitOps)", "name", ns.Name, "key", s.GitOpsOwnershipKey)
+			s.eventf(ns, corev1.EventTypeNormal, "SkippedGitOpsOwned",
+				"Not swept: namespace carries %s; set %s=true to sweep it anyway", s.GitOpsOwnershipKey, AnnotationEnforce)
+			note(ns, DispositionExcluded, "owned by GitOps ("+s.GitOpsOwnershipKey+")")
+			continue
+		}
+
+		if emptyTTLAnnotation(ns.Annotations) {
+			if s.EmptyTTLNever {
+				protected++
+				logger.V(1).Info("Skipping namespace (empty ttl annotation means never)", "name", ns.Name)
+				note(ns, DispositionProtected, "empty ttl annotation, --empty-ttl-means=never")
+				continue
+			}
+			logger.V(1).Info("Empty ttl annotation, using the default TTL", "name", ns.Name, "ttl", s.TTL.String())
+			step("empty ttl annotation, the default TTL applies")
+		}
+
+		effectiveTTL, ttlSrc := s.cachedResolveTTL(ns)
+		if policy != nil && ttlSrc == "default" {
+			effectiveTTL, ttlSrc = policy.ttl, "sweeppolicy:"+policy.name
+		}
+		problems := ttlProblems(ns.Annotations, ttlSrc)
+		if name, ok := s.unknownPolicy(ns.Annotations); ok {
+			logger.Info("Unknown TTL policy, the default TTL applies", "name", ns.Name, "policy", name)
+			problems = append(problems, fmt.Sprintf("unknown %s %q, the default TTL applies", AnnotationPolicy, name))
+		}
+		step("resolved TTL %s from %s", effectiveTTL, ttlSrc)
+		var requestedTTL time.Duration
+		if ttlSrc == "annotation" && effectiveTTL > 0 && effectiveTTL < s.MinTTL {
+			logger.V(1).Info("Raising ttl annotation to --min-ttl", "name", ns.Name, "requested", effectiveTTL.String(), "minTTL", s.MinTTL.String())
+			requestedTTL, effectiveTTL = effectiveTTL, s.MinTTL
+			step("raised to --min-ttl %s", s.MinTTL)
+		}
+		if ttl, ok := s.staleTTL(ns, effectiveTTL); ok {
+			logger.V(1).Info("Shortening TTL of never-touched namespace", "name", ns.Name, "ttl", effectiveTTL.String(), "shortened", ttl.String())
+			step("shortened to %s by --stale-factor %g, never updated since creation", ttl, s.StaleFactor)
+			effectiveTTL, ttlSrc = ttl, "stale"
+		}
+
+		seenTTLs[ns.UID] = effectiveTTL
+		if prev, ok := s.lastTTLs[ns.UID]; ok && prev != effectiveTTL && !evaluating {
+			ttlChangesTotal.Inc()
+			logger.Info("Namespace TTL changed since last sweep", "name", ns.Name, "from", prev.String(), "to", effectiveTTL.String(), "ttlSource", ttlSrc)
+			s.eventf(ns, corev1.EventTypeNormal, "TTLChanged",
+				"Effective TTL changed from %s to %s (%s)", prev, effectiveTTL, ttlSrc)
+		}
 
-		effectiveTTL, ttlSrc := resolveTTL(ns.Annotations, s.TTL)
+		deleteNow := ns.Annotations[AnnotationDeleteNow] == "true"
 
-		if ns.Annotations[AnnotationHold] == "true" {
+		source, reason, isHeld := holdOf(ns, registryHolds, now)
+		holdKey := AnnotationHold
+		if !isHeld && policy != nil && policy.holdAnnotation != "" && ns.Annotations[policy.holdAnnotation] == "true" {
+			source, holdKey, isHeld = HoldSourceAnnotation, policy.holdAnnotation, true
+		}
+		holdEnd, timedHold := holdUntil(ns.Annotations)
+		timedHold = timedHold && !holdEnd.IsZero()
+		if timedHold && !now.Before(holdEnd) {
+			// Left in place it would repeat every sweep; a hold from another source says more
+			if !isHeld {
+				logger.V(1).Info("Hold has expired, the TTL applies", "name", ns.Name, "holdUntil", holdEnd, "active", false)
+			}
+			step("hold expired at %s", holdEnd.UTC().Format(time.RFC3339))
+		}
+		if isHeld && !(deleteNow && s.DeleteNowOverridesHold) {
+			held++
+			if source == HoldSourceRegistry {
+				logger.Info("Skipping namespace (held by registry)", "name", ns.Name, "reason", reason,
+					"configMap", s.HoldRegistry.String(), "ttlSource", ttlSrc, "ttl", effectiveTTL.String())
+				s.eventf(ns, corev1.EventTypeNormal, "HeldByRegistry",
+					"Held by hold registry %s: %s", s.HoldRegistry, reason)
+				note(ns, DispositionHeld, "hold registry: "+reason).Problems = problems
+				continue
+			}
+			if holdKey == AnnotationHold && timedHold {
+				logger.Info("Skipping namespace (on-hold enabled)", "name", ns.Name, "holdUntil", holdEnd, "active", true,
+					"ttlSource", ttlSrc, "ttl", effectiveTTL.String())
+				heldByAnnotation[ns.UID] = struct{}{}
+				s.heldEventf(ns, holdKey, effectiveTTL, ttlSrc)
+				note(ns, DispositionHeld, holdKey+" until "+holdEnd.UTC().Format(time.RFC3339)).Problems = problems
+				continue
+			}
 			logger.Info("Skipping namespace (on-hold enabled)", "name", ns.Name, "ttlSource", ttlSrc, "ttl", effectiveTTL.String())
+			heldByAnnotation[ns.UID] = struct{}{}
+			s.heldEventf(ns, holdKey, effectiveTTL, ttlSrc)
+			note(ns, DispositionHeld, holdKey).Problems = problems
+			continue
+		}
+
+		if deleteNow {
+			age := now.Sub(ns.CreationTimestamp.Time)
+			expired++
+			logger.Info("Deletion requested via annotation", "name", ns.Name, "age", age)
+			s.eventf(ns, corev1.EventTypeNormal, "DeleteRequested",
+				"Deletion requested via %s annotation", AnnotationDeleteNow)
+			toDelete = append(toDelete, expiredNamespace{ns: ns, policyDryRun: policyDryRun, age: age, ttl: effectiveTTL, ttlSource: "delete-now",
+				reason: fmt.Sprintf("deletion requested via the %s annotation", AnnotationDeleteNow)})
+			e := note(ns, DispositionWouldDelete, AnnotationDeleteNow)
+			e.Age, e.Problems = age.String(), problems
+			continue
+		}
+
+		if state, ok := sourceClosed(ns); ok {
+			age := now.Sub(ns.CreationTimestamp.Time)
+			expired++
+			logger.Info("Source branch or PR is closed", "name", ns.Name, "state", state, "age", age)
+			s.eventf(ns, corev1.EventTypeNormal, "SourceClosed",
+				"Deletion requested: %s is %q", AnnotationSourceState, state)
+			toDelete = append(toDelete, expiredNamespace{ns: ns, policyDryRun: policyDryRun, age: age, ttl: effectiveTTL, ttlSource: "source-state",
+				reason: fmt.Sprintf("%s is %q", AnnotationSourceState, state)})
+			e := note(ns, DispositionWouldDelete, "source "+state)
+			e.Age, e.Problems = age.String(), problems
+			continue
+		}
+
+		if s.Liveness != nil && livenessUnreachable {
+			step("external liveness check skipped, the endpoint was unreachable earlier this sweep; treated as alive")
+		} else if s.Liveness != nil {
+			alive, err := s.Liveness.Alive(ctx, ns)
+			if errors.Is(err, errLivenessUnreachable) {
+				// Each further check would likely wait out its timeout too, eating into --sweep-timeout
+				livenessUnreachable = true
+				logger.Error(err, "External liveness endpoint unreachable, treating the remaining namespaces as alive this sweep", "name", ns.Name)
+				step("external liveness check failed, treated as alive: %v", err)
+			} else if err != nil {
+				logger.Error(err, "External liveness check failed, treating namespace as alive", "name", ns.Name)
+				step("external liveness check failed, treated as alive: %v", err)
+			} else if alive {
+				step("external liveness check: alive")
+			}
+			if !alive {
+				age := now.Sub(ns.CreationTimestamp.Time)
+				expired++
+				logger.Info("Linked external resource is gone", "name", ns.Name, "age", age)
+				s.eventf(ns, corev1.EventTypeNormal, "ExternalResourceGone",
+					"External liveness check reported the linked resource gone")
+				toDelete = append(toDelete, expiredNamespace{ns: ns, policyDryRun: policyDryRun, age: age, ttl: effectiveTTL, ttlSource: "external-liveness",
+					reason: "the external liveness check reported the linked resource gone"})
+				e := note(ns, DispositionWouldDelete, "linked external resource is gone")
+				e.Age, e.Problems = age.String(), problems
+				continue
+			}
+		}
+
+		// An absolute expiry replaces the TTL, its grace period and confirmation
+		if expiresAt, set, err := expiresAtAnnotation(ns.Annotations); set {
+			age := now.Sub(ns.CreationTimestamp.Time)
+			if err != nil {
+				logger.Info("Skipping namespace (invalid expires-at annotation)", "name", ns.Name,
+					"value", ns.Annotations[AnnotationExpiresAt], "error", err.Error())
+				e := note(ns, DispositionMisconfigured, "invalid "+AnnotationExpiresAt+" annotation")
+				e.Age, e.Problems = age.String(), append(problems, err.Error())
+				continue
+			}
+			ttl := expiresAt.Sub(ns.CreationTimestamp.Time)
+			if !now.After(expiresAt) {
+				e := note(ns, DispositionKept, "")
+				e.Age, e.TTL, e.TTLSource, e.Problems = age.String(), ttl.String(), "expires-at", problems
+				e.ExpiresAt = &expiresAt
+				continue
+			}
+			expired++
+			oldestOverdue = max(oldestOverdue, now.Sub(expiresAt))
+			logger.Info("Namespace passed its expires-at time", "name", ns.Name, "expiresAt", expiresAt, "age", age)
+			toDelete = append(toDelete, expiredNamespace{ns: ns, policyDryRun: policyDryRun, age: age, ttl: ttl, ttlSource: "expires-at"})
+			e := note(ns, DispositionWouldDelete, AnnotationExpiresAt+" passed")
+			e.Age, e.TTL, e.TTLSource, e.Problems = age.String(), ttl.String(), "expires-at", problems
 			continue
 		}
 
 		if effectiveTTL <= 0 {
 			logger.Info("Skipping namespace (non-positive TTL)", "name", ns.Name, "ttlSource", ttlSrc, "ttl", effectiveTTL.String())
+			e := note(ns, DispositionMisconfigured, "non-positive TTL")
+			e.TTL, e.TTLSource, e.Problems = effectiveTTL.String(), ttlSrc, problems
 			continue
 		}
+		// Added after TTL change tracking, so toggling maintenance doesn't look like a TTL change
+		effectiveTTL += bonus
+		if bonus > 0 {
+			step("maintenance bonus %s added", bonus)
+		}
+
+		grace, graceSrc := s.resolveGrace(ns.Annotations)
+		if problem, ok := graceProblem(ns.Annotations, graceSrc); ok {
+			logger.Info("Ignoring invalid grace annotation", "name", ns.Name, "value", ns.Annotations[AnnotationGrace], "grace", grace.String())
+			problems = append(problems, problem)
+		}
+		if grace > 0 {
+			step("grace period %s from %s", grace, graceSrc)
+		}
 
-		age := now.Sub(ns.CreationTimestamp.Time)
-		if age <= effectiveTTL {
+		start, ageSrc, err := s.ageStart(ctx, ns)
+		if err != nil {
+			logger.Error(err, "Failed to list workloads for the namespace age, keeping it", "name", ns.Name)
+			e := note(ns, DispositionKept, "listing workloads failed")
+			e.TTL, e.TTLSource, e.Problems = effectiveTTL.String(), ttlSrc, problems
+			continue
+		}
+		if ageSrc != AgeSourceCreation {
+			step("age counted from %s at %s", ageSrc, start.UTC().Format(time.RFC3339))
+		}
+		age := now.Sub(start)
+		if age <= effectiveTTL+grace && s.IdleTTL > 0 {
+			idle, err := s.idleFor(ctx, ns, now, busy)
+			if err != nil {
+				logger.Error(err, "Failed to list pods for --idle-ttl, treating namespace as busy", "name", ns.Name)
+				step("listing pods for --idle-ttl failed, treated as busy: %v", err)
+			} else if idle > s.IdleTTL {
+				expired++
+				oldestOverdue = max(oldestOverdue, idle-s.IdleTTL)
+				logger.Info("Namespace has had no pods for longer than --idle-ttl", "name", ns.Name,
+					"idle", idle, "idleTTL", s.IdleTTL.String(), "age", age)
+				toDelete = append(toDelete, expiredNamespace{ns: ns, policyDryRun: policyDryRun, age: age, ttl: s.IdleTTL, ttlSource: "idle",
+					reason: fmt.Sprintf("no pods for %s, longer than the idle TTL %s", idle.Round(time.Second), s.IdleTTL)})
+				e := note(ns, DispositionWouldDelete, "no pods for longer than --idle-ttl")
+				e.Age, e.TTL, e.TTLSource, e.Problems = age.String(), s.IdleTTL.String(), "idle", problems
+				continue
+			} else if idle > 0 {
+				step("no pods for %s of --idle-ttl %s", idle, s.IdleTTL)
+			}
+		}
+		if age <= effectiveTTL+grace {
+			if left := effectiveTTL + grace - age; s.CountdownWindow > 0 && left <= s.CountdownWindow && !evaluating {
+				label := NormalizeLabelValue(ns.Name, s.MetricLabelMaxLen)
+				timeToDeletion.WithLabelValues(label).Set(left.Seconds())
+				countdown[label] = struct{}{}
+			}
+			deleteAt := start.Add(effectiveTTL + grace)
+			reason := ""
+			if age > effectiveTTL {
+				reason = "in grace period"
+				logger.Info("Namespace expired, deleting after its grace period", "name", ns.Name,
+					"ttl", effectiveTTL.String(), "ttlSource", ttlSrc, "grace", grace.String(), "graceSource", graceSrc, "deleteAfter", deleteAt)
+				// Identical messages each sweep are aggregated into one event by the recorder
+				s.eventf(ns, corev1.EventTypeWarning, "DeletionPending",
+					"TTL %s ran out; the namespace will be deleted after %s", effectiveTTL, deleteAt.UTC().Format(time.RFC3339))
+				if s.ScaleToZero && ns.Annotations[AnnotationScaledToZero] == "" {
+					switch {
+					case s.AuditOnly:
+						step("would scale workloads to zero")
+					case blocked != "":
+						logger.Info("Not scaling workloads to zero, deletions are blocked", "name", ns.Name, "reason", blocked)
+					case s.dryRunFor(ns, policyDryRun):
+						logger.Info("[dry-run] Would scale workloads to zero", "name", ns.Name)
+					default:
+						if n, err := s.scaleToZero(ctx, ns, now); err != nil {
+							logger.Error(err, "Failed to scale workloads to zero", "name", ns.Name)
+						} else {
+							logger.Info("Scaled workloads to zero", "name", ns.Name, "workloads", n, "deleteAfter", deleteAt)
+							s.eventf(ns, corev1.EventTypeNormal, "ScaledToZero",
+								"Scaled %d workloads to zero replicas; the namespace will be deleted after %s", n, deleteAt.UTC().Format(time.RFC3339))
+						}
+					}
+				}
+			}
+			e := note(ns, DispositionKept, reason)
+			e.Age, e.TTL, e.TTLSource, e.Problems = age.String(), effectiveTTL.String(), ttlSrc, problems
+			e.ExpiresAt = &deleteAt
 			continue
 		}
 		expired++
+		oldestOverdue = max(oldestOverdue, age-effectiveTTL)
+		if s.ConfirmThreshold > 0 && age > s.ConfirmThreshold && ns.Annotations[AnnotationConfirmDelete] != "true" {
+			needConfirmation++
+			logger.Info("Not deleting expired namespace without confirmation", "name", ns.Name, "age", age,
+				"confirmThreshold", s.ConfirmThreshold.String(), "annotation", AnnotationConfirmDelete)
+			s.eventf(ns, corev1.EventTypeWarning, "ConfirmationRequired",
+				"Expired, but older than %s: set %s=true to delete it, or hold it", s.ConfirmThreshold, AnnotationConfirmDelete)
+			e := note(ns, DispositionHeld, "older than --confirm-threshold, needs "+AnnotationConfirmDelete+"=true")
+			e.Age, e.TTL, e.TTLSource, e.Problems = age.String(), effectiveTTL.String(), ttlSrc, problems
+			continue
+		}
+		toDelete = append(toDelete, expiredNamespace{ns: ns, policyDryRun: policyDryRun, age: age, ttl: effectiveTTL, ttlSource: ttlSrc, requestedTTL: requestedTTL})
+		e := note(ns, DispositionWouldDelete, "age exceeded TTL")
+		e.Age, e.TTL, e.TTLSource, e.Problems = age.String(), effectiveTTL.String(), ttlSrc, problems
+	}
 
-		if s.DryRun {
-			deletedTotal.WithLabelValues("dry_run").Inc()
-			logger.Info("[dry-run] Would delete expired namespace", "name", ns.Name, "age", age, "ttlSource", ttlSrc, "ttl", effectiveTTL.String())
-			if s.Recorder != nil {
-				s.Recorder.Eventf(ns, corev1.EventTypeNormal, "NamespaceCleanupDryRun",
-					"[dry-run] Would delete namespace %q: age %s exceeded TTL %s (%s)", ns.Name, age, effectiveTTL, ttlSrc)
+	if s.SettlePeriod > 0 {
+		// Remember the resourceVersions after this sweep's own writes, so they don't count as changes
+		for i := range nsList.Items {
+			if st, ok := changes[nsList.Items[i].UID]; ok {
+				st.resourceVersion = nsList.Items[i].ResourceVersion
+				changes[nsList.Items[i].UID] = st
 			}
-			continue
 		}
+		if !evaluating {
+			s.lastChanges = changes
+		}
+		for i := range toDelete {
+			toDelete[i].settleLeft = s.SettlePeriod - now.Sub(changes[toDelete[i].ns.UID].since)
+		}
+	}
 
-		logger.Info("Deleting expired namespace", "name", ns.Name, "age", age, "ttlSource", ttlSrc, "ttl", effectiveTTL.String())
-		if err := s.Client.Delete(ctx, ns); err != nil {
-			deletedTotal.WithLabelValues("error").Inc()
-			logger.Error(err, "Failed to delete namespace", "name", ns.Name)
-			continue
+	if s.ShadowSelector != nil && !evaluating {
+		active := make(map[string]bool, len(toDelete))
+		for _, e := range toDelete {
+			active[e.ns.Name] = true
 		}
-		deletedTotal.WithLabelValues("deleted").Inc()
-		deleted++
+		if shadow, ok := s.shadowDecisions(ctx); ok {
+			s.compareShadow(logger, active, shadow)
+		} else {
+			logger.Info("Could not evaluate --shadow-selector, skipping the comparison this sweep")
+		}
+	}
 
-		if s.Recorder != nil {
-			s.Recorder.Eventf(ns, corev1.EventTypeNormal, "NamespaceCleanup",
-				"Deleted namespace %q: age %s exceeded TTL %s (%s)", ns.Name, age, effectiveTTL, ttlSrc)
+	if s.AuditOnly {
+		report := AuditReport{GeneratedAt: now, Blocked: blocked, Namespaces: audit}
+		if evaluating {
+			s.auditSink(report)
+			return SweepResult{Scanned: scanned, Candidates: candidates, Expired: expired}
+		}
+		if err := writeAuditReport(s.AuditOutput, os.Stdout, report); err != nil {
+			logger.Error(err, "Failed to write audit report", "path", s.AuditOutput)
+		} else {
+			logger.Info("Audit report written, nothing was deleted", "path", s.AuditOutput, "namespaces", len(audit))
+		}
+		toDelete = nil
+	}
+
+	// Oldest first, so whatever a limit defers is the most recently expired
+	sort.SliceStable(toDelete, func(i, j int) bool { return toDelete[i].age > toDelete[j].age })
+	if limit := s.deleteLimit(len(toDelete)); limit < len(toDelete) {
+		logger.Info("Deferring expired namespaces to later sweeps",
+			"expired", len(toDelete), "deleting", limit, "deferred", len(toDelete)-limit)
+		toDelete = toDelete[:limit]
+	}
+
+	if s.OrderedDelete {
+		sortByDeleteOrder(toDelete)
+	}
+
+	var reported []ReportedNamespace
+	var records []DeletionRecord
+	var tallyMu sync.Mutex
+	tally := func(e expiredNamespace, ok, dryRun bool) {
+		tallyMu.Lock()
+		defer tallyMu.Unlock()
+		if ok || dryRun {
+			records = append(records, DeletionRecord{
+				Namespace: e.ns.Name, DeletedAt: time.Now().UTC(), Age: e.age.String(), TTL: e.ttl.String(),
+				TTLSource: e.ttlSource, DryRun: dryRun, Controller: s.ControllerID,
+			})
+		}
+		if ok && s.Reporter != nil {
+			reported = append(reported, ReportedNamespace{
+				Name: e.ns.Name, Age: e.age.String(), TTL: e.ttl.String(), TTLSource: e.ttlSource, Labels: e.ns.Labels,
+			})
+		}
+	}
+	window := s.deleteSpreadWindow(start)
+	deadline := time.Now().Add(window)
+	gaps := len(toDelete) - 1
+	if s.MaxDeletesPerSweep > 0 {
+		gaps = min(gaps, s.MaxDeletesPerSweep-1)
+	}
+	if s.Concurrency > 1 && window == 0 {
+		deleted = s.deleteConcurrently(ctx, logger, toDelete, blocked, tally)
+	} else {
+		for i, e := range toDelete {
+			if ctx.Err() != nil {
+				logger.Info("Stopping deletions", "reason", ctx.Err().Error(), "remaining", len(toDelete)-i)
+				break
+			}
+			if s.MaxDeletesPerSweep > 0 && deleted >= s.MaxDeletesPerSweep {
+				logger.Info("Reached the per-sweep deletion cap, deferring the rest to the next sweep",
+					"maxDeletesPerSweep", s.MaxDeletesPerSweep, "deferred", len(toDelete)-i)
+				break
+			}
+			ok, dryRun := s.deleteExpired(ctx, logger, e, blocked)
+			tally(e, ok, dryRun)
+			if !ok {
+				continue
+			}
+			deleted++
+			capped := s.MaxDeletesPerSweep > 0 && deleted >= s.MaxDeletesPerSweep
+			if left := len(toDelete) - i - 1; window > 0 && left > 0 && !capped {
+				pause := min(s.withJitter(window/time.Duration(gaps), 0.5), time.Until(deadline))
+				if err := sleepCtx(ctx, pause); err != nil {
+					logger.Info("Stopping spread deletions", "reason", err.Error(), "remaining", left)
+					break
+				}
+			}
 		}
 	}
 
 	// update gauges
 	lastCandidates.Set(float64(candidates))
+	setCandidatesByTTLSource(bySource)
 	lastExpired.Set(float64(expired))
 	lastDeleted.Set(float64(deleted))
+	lastMissingAnnotation.Set(float64(missingAnnotation))
+	lastAnnotationMismatch.Set(float64(mismatch))
+	protectedByAnnotation.Set(float64(protected))
+	lastGitOpsOwned.Set(float64(gitOpsOwned))
+	lastConfirmationRequired.Set(float64(needConfirmation))
+	lastHeld.Set(float64(held))
+	oldestExpiredAge.Set(oldestOverdue.Seconds())
+
+	if s.Reporter != nil && !s.AuditOnly {
+		s.Reporter.Report(logger, SweepReport{
+			Controller: s.ControllerID, StartedAt: start, Duration: time.Since(start).String(),
+			DryRun: s.DryRun, Blocked: blocked,
+			Scanned: scanned, Candidates: candidates, Expired: expired, Deleted: deleted,
+			DeletedNamespaces: reported,
+		})
+	}
+
+	if s.DeletionLog.Name != "" && len(records) > 0 {
+		// Sweeps cut short by shutdown or --sweep-timeout end with ctx cancelled, yet may have
+		// deleted namespaces that must be recorded
+		logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deletionLogTimeout)
+		err := s.appendDeletionLog(logCtx, records)
+		cancel()
+		if err != nil {
+			logger.Error(err, "Failed to append to the deletion log", "configMap", s.DeletionLog.String(), "records", len(records))
+		}
+	}
+
+	if s.LivenessLease.Name != "" && !s.AuditOnly {
+		if err := s.renewLivenessLease(ctx, time.Now()); err != nil {
+			logger.Error(err, "Failed to renew the liveness lease", "lease", s.LivenessLease.String())
+		}
+	}
+
+	return SweepResult{Scanned: scanned, Candidates: candidates, Expired: expired, Deleted: deleted}
 }
 
-// annotation example: preview-sweeper.maxsauce.com/ttl="4h", "30m", "2h45m", "69" (int = hours)
-func resolveTTL(annotations map[string]string, defaultTTL time.Duration) (time.Duration, string) {
-	if annotations != nil {
-		if raw, ok := annotations[AnnotationTTL]; ok {
-			val := strings.TrimSpace(raw)
-			if val != "" {
-				if d, err := time.ParseDuration(val); err == nil {
-					return d, "annotation"
-				}
-				if n, err := strconv.Atoi(val); err == nil {
-					return time.Duration(n) * time.Hour, "annotation"
-				}
+// notTerminating leaves terminating namespaces out of a List on the API server's side.
+var notTerminating = fields.OneTermNotEqualSelector("status.phase", string(corev1.NamespaceTerminating))
+
+// listNamespaces lists the namespaces matching sel that aren't terminating. Not every list path
+// takes the field selector: the informer cache refuses anything but exact matches. When a List
+// with it fails and one without succeeds, the sweep goes on without it, and if the failure was the
+// selector being rejected, so do later sweeps; the sweep skips terminating namespaces either way.
+func (s *NamespaceSweeper) listNamespaces(ctx context.Context, logger logr.Logger, list *corev1.NamespaceList, sel labels.Selector) error {
+	if s.phaseSelectorUnsupported {
+		return s.Client.List(ctx, list, &client.ListOptions{LabelSelector: sel})
+	}
+	err := s.Client.List(ctx, list, &client.ListOptions{LabelSelector: sel, FieldSelector: notTerminating})
+	if err == nil {
+		return nil
+	}
+	if retryErr := s.Client.List(ctx, list, &client.ListOptions{LabelSelector: sel}); retryErr != nil {
+		return retryErr
+	}
+	if fieldSelectorRejected(err) {
+		logger.Info("Listing namespaces without the status.phase field selector, the client does not support it", "error", err.Error())
+		s.phaseSelectorUnsupported = true
+	}
+	return nil
+}
+
+// fieldSelectorRejected reports whether a List failed because of its field selector: the API
+// server answers BadRequest, the cache and the fake client refuse non-exact matches.
+func fieldSelectorRejected(err error) bool {
+	if apierrors.IsBadRequest(err) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "field selector") || strings.Contains(msg, "field matches are not supported")
+}
+
+// resetSweepGauges zeroes the gauges describing the last sweep, for when there is no valid one.
+func (s *NamespaceSweeper) resetSweepGauges() {
+	lastScanned.Set(0)
+	lastCandidates.Set(0)
+	candidatesByTTLSource.Reset()
+	lastExpired.Set(0)
+	lastDeleted.Set(0)
+	lastMissingAnnotation.Set(0)
+	lastAnnotationMismatch.Set(0)
+	protectedByAnnotation.Set(0)
+	lastGitOpsOwned.Set(0)
+	lastConfirmationRequired.Set(0)
+	lastHeld.Set(0)
+	oldestExpiredAge.Set(0)
+	timeToDeletion.Reset()
+	shadowDifference.Reset()
+	s.countdown = nil
+}
+
+// candidateTTLSource buckets a candidate by what sets its TTL for candidates_by_ttl_source:
+// "expires-at", "annotation" for the ttl or policy annotation, "sweeppolicy" for the TTL of its
+// SweepPolicy, or "default" for --ttl. Unlike the ttlSource of the sweep, it names no policy, so
+// the label stays within these four values.
+func (s *NamespaceSweeper) candidateTTLSource(annotations map[string]string, policy *sweepPolicy) string {
+	if _, set, _ := expiresAtAnnotation(annotations); set {
+		return "expires-at"
+	}
+	if _, src := s.resolveTTL(annotations); src != "default" {
+		return "annotation"
+	}
+	if policy != nil {
+		return "sweeppolicy"
+	}
+	return "default"
+}
+
+// setCandidatesByTTLSource replaces candidates_by_ttl_source with the counts of this sweep. All
+// sources are set, so one nothing uses any more reads 0 instead of its last count.
+func setCandidatesByTTLSource(bySource map[string]int) {
+	for _, src := range []string{"default", "annotation", "sweeppolicy", "expires-at"} {
+		candidatesByTTLSource.WithLabelValues(src).Set(float64(bySource[src]))
+	}
+}
+
+// bumpSweepCount increments AnnotationSweepCount with a merge patch, so it never conflicts with
+// concurrent writers. An unparseable count starts over at 1.
+func (s *NamespaceSweeper) bumpSweepCount(ctx context.Context, ns *corev1.Namespace) error {
+	count, _ := strconv.Atoi(ns.Annotations[AnnotationSweepCount])
+	patch := client.MergeFrom(ns.DeepCopy())
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[AnnotationSweepCount] = strconv.Itoa(max(count, 0) + 1)
+	return s.Client.Patch(ctx, ns, patch, s.fieldOwner())
+}
+
+// fieldOwner is the field manager option passed to every write.
+func (s *NamespaceSweeper) fieldOwner() client.FieldOwner {
+	if s.FieldManager == "" {
+		return DefaultFieldManager
+	}
+	return client.FieldOwner(s.FieldManager)
+}
+
+// sortByDeleteOrder stably sorts by AnnotationDeleteOrder; a missing or invalid order sorts last.
+func sortByDeleteOrder(toDelete []expiredNamespace) {
+	order := func(ns *corev1.Namespace) int {
+		if n, err := strconv.Atoi(strings.TrimSpace(ns.Annotations[AnnotationDeleteOrder])); err == nil {
+			return n
+		}
+		return math.MaxInt
+	}
+	sort.SliceStable(toDelete, func(i, j int) bool { return order(toDelete[i].ns) < order(toDelete[j].ns) })
+}
+
+// updateCountdown drops time_to_deletion_seconds series for namespaces no longer near expiry.
+// current holds normalized label values, not raw names.
+func (s *NamespaceSweeper) updateCountdown(current map[string]struct{}) {
+	for name := range s.countdown {
+		if _, ok := current[name]; !ok {
+			timeToDeletion.DeleteLabelValues(name)
+		}
+	}
+	s.countdown = current
+}
+
+// deleteExpired runs the delete phase for one expired namespace and reports whether it was
+// deleted, or whether dry-run kept it from being deleted.
+func (s *NamespaceSweeper) deleteExpired(ctx context.Context, logger logr.Logger, e expiredNamespace, blocked string) (deleted, dryRun bool) {
+	ns, age, effectiveTTL, ttlSrc := e.ns, e.age, e.ttl, e.ttlSource
+	reason := e.reason
+	if reason == "" {
+		reason = fmt.Sprintf("age %s exceeded TTL %s (%s)", age, effectiveTTL, ttlSrc)
+	}
+
+	if s.MinAge > 0 {
+		if created := time.Now().Add(s.ClockSkew).Sub(ns.CreationTimestamp.Time); created < s.MinAge {
+			logger.Info("Deferring deletion (younger than --min-age)", "name", ns.Name, "sinceCreation", created.Round(time.Second).String(),
+				"minAge", s.MinAge.String(), "ttl", effectiveTTL.String(), "ttlSource", ttlSrc)
+			return false, false
+		}
+	}
+
+	if e.settleLeft > 0 {
+		logger.Info("Deferring deletion (metadata changed recently)", "name", ns.Name,
+			"settlePeriod", s.SettlePeriod.String(), "settleLeft", e.settleLeft.Round(time.Second).String())
+		return false, false
+	}
+
+	if s.BlockOnPendingLB {
+		svc, err := s.pendingLoadBalancer(ctx, ns.Name)
+		if err != nil {
+			logger.Error(err, "Failed to list services, deferring deletion", "name", ns.Name)
+			return false, false
+		}
+		if svc != "" {
+			logger.Info("Deferring deletion (LoadBalancer service pending)", "name", ns.Name, "service", svc)
+			s.eventf(ns, corev1.EventTypeNormal, "DeferredPendingLB",
+				"Deletion deferred: LoadBalancer service %q is still provisioning or deprovisioning", svc)
+			return false, false
+		}
+	}
+
+	if blocked != "" {
+		logger.Info("Not deleting expired namespace", "name", ns.Name, "age", age, "reason", blocked)
+		return false, false
+	}
+
+	if s.dryRunFor(ns, e.policyDryRun) {
+		s.countDeletion("dry_run", ttlSrc)
+		logger.Info("[dry-run] Would delete expired namespace", "name", ns.Name, "age", age, "ttlSource", ttlSrc, "ttl", effectiveTTL.String(), "controller", s.ControllerID)
+		def := fmt.Sprintf("[dry-run] Would delete namespace %q: %s%s", ns.Name, reason, s.byController())
+		s.eventf(ns, corev1.EventTypeNormal, "NamespaceCleanupDryRun", "%s", s.deletionMessage(logger, EventMessageData{
+			Namespace: ns, Age: age, TTL: effectiveTTL, TTLSource: ttlSrc, Reason: reason, DryRun: true, Controller: s.ControllerID,
+		}, def))
+		s.notify(ctx, logger, Notification{
+			Namespace: ns.Name, Age: age.String(), TTL: effectiveTTL.String(), TTLSource: ttlSrc, DryRun: true, Controller: s.ControllerID,
+		})
+		return false, true
+	}
+
+	if s.PreDelete != nil {
+		allow, output, err := s.PreDelete.Allow(ctx, ns)
+		switch {
+		case err != nil && !allow:
+			logger.Error(err, "Pre-delete command failed, deferring deletion", "name", ns.Name, "output", output)
+			return false, false
+		case err != nil:
+			logger.Error(err, "Pre-delete command failed, deleting anyway (fail-open)", "name", ns.Name, "output", output)
+		case !allow:
+			logger.Info("Deferring deletion (pre-delete command vetoed it)", "name", ns.Name, "command", s.PreDelete.Path, "output", output)
+			s.eventf(ns, corev1.EventTypeNormal, "DeferredPreDelete",
+				"Deletion deferred: pre-delete command %s exited non-zero", s.PreDelete.Path)
+			return false, false
+		default:
+			logger.V(1).Info("Pre-delete command allowed deletion", "name", ns.Name, "output", output)
+		}
+	}
+
+	var deleteOpts []client.DeleteOption
+	if s.PropagationPolicy != "" {
+		deleteOpts = append(deleteOpts, client.PropagationPolicy(s.PropagationPolicy))
+	}
+	target := ns
+	if s.APIReader != nil {
+		fresh, err := s.freshCopy(ctx, ns)
+		if err != nil {
+			logger.Error(err, "Failed to re-read namespace before deletion", "name", ns.Name)
+			return false, false
+		}
+		if fresh == nil {
+			logger.Info("Namespace changed since it was listed, re-evaluating next sweep", "name", ns.Name)
+			return false, false
+		}
+		target = fresh
+	}
+	if s.StampOnDelete {
+		// The stamp is best-effort; a namespace is never kept for lack of it
+		if err := s.stampDeletion(ctx, target, time.Now()); err != nil && !shuttingDown(ctx) {
+			stampErrorsTotal.Inc()
+			logger.Error(err, "Failed to stamp namespace before deleting it, deleting anyway", "name", ns.Name)
+		}
+	}
+	if s.APIReader != nil {
+		deleteOpts = append(deleteOpts, client.Preconditions{UID: &target.UID, ResourceVersion: &target.ResourceVersion})
+	}
+
+	logger.Info("Deleting expired namespace", "name", ns.Name, "age", age, "ttlSource", ttlSrc, "ttl", effectiveTTL.String(), "controller", s.ControllerID)
+	s.notify(ctx, logger, Notification{
+		Namespace: ns.Name, Age: age.String(), TTL: effectiveTTL.String(), TTLSource: ttlSrc, Controller: s.ControllerID,
+	})
+	deleteCtx, span := s.tracer().Start(ctx, "DeleteNamespace", trace.WithAttributes(attribute.String("namespace", ns.Name)))
+	err := s.deleteWithRetry(deleteCtx, logger, target, s.APIReader == nil, deleteOpts...)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+	switch {
+	case apierrors.IsNotFound(err):
+		// Deleted by someone else since the List, a benign race rather than a failure
+		s.countDeletion("already_gone", ttlSrc)
+		logger.Info("Namespace was already gone", "name", ns.Name)
+		return false, false
+	case err != nil && shuttingDown(ctx):
+		// Not a failure: the next sweep, on this replica or the next leader, picks it up again
+		logger.Info("Delete interrupted by shutdown", "name", ns.Name, "error", err.Error())
+		return false, false
+	case apierrors.IsForbidden(err):
+		s.countDeletion("error", ttlSrc)
+		deleteErrorsTotal.WithLabelValues(deleteErrorReason(err)).Inc()
+		logger.Info("Not allowed to delete namespace, skipping it", "name", ns.Name, "error", err.Error())
+		return false, false
+	case err != nil:
+		s.countDeletion("error", ttlSrc)
+		reason := deleteErrorReason(err)
+		deleteErrorsTotal.WithLabelValues(reason).Inc()
+		logger.Error(err, "Failed to delete namespace", "name", ns.Name, "reason", reason)
+		return false, false
+	}
+	s.countDeletion("deleted", ttlSrc)
+	lastDeleteNanos.Store(time.Now().UnixNano())
+	if e.requestedTTL > 0 {
+		ttlBelowMinTotal.Inc()
+		logger.Info("Deleted namespace had a ttl annotation below --min-ttl", "name", ns.Name,
+			"requested", e.requestedTTL.String(), "minTTL", s.MinTTL.String())
+	}
+
+	def := fmt.Sprintf("Deleted namespace %q: %s%s", ns.Name, reason, s.byController())
+	s.eventf(ns, corev1.EventTypeNormal, "NamespaceCleanup", "%s", s.deletionMessage(logger, EventMessageData{
+		Namespace: ns, Age: age, TTL: effectiveTTL, TTLSource: ttlSrc, Reason: reason, Controller: s.ControllerID,
+	}, def))
+	return true, false
+}
+
+// deleteAttempts bounds the deletes deleteWithRetry makes for one namespace.
+const deleteAttempts = 3
+
+// deleteWithRetry deletes ns, retrying with exponential backoff on errors that are likely to pass:
+// server timeouts, throttling, an unavailable API server, and conflicts if retryConflicts (a
+// conflict on a delete with preconditions means the namespace changed, which retrying won't fix).
+func (s *NamespaceSweeper) deleteWithRetry(ctx context.Context, logger logr.Logger, ns *corev1.Namespace, retryConflicts bool, opts ...client.DeleteOption) error {
+	backoff := s.DeleteRetryBackoff
+	if backoff <= 0 {
+		backoff = 200 * time.Millisecond
+	}
+	for attempt := 1; ; attempt++ {
+		err := s.Client.Delete(ctx, ns, opts...)
+		if err == nil || attempt >= deleteAttempts || !retryableDeleteError(err, retryConflicts) {
+			return err
+		}
+		wait := backoff << (attempt - 1)
+		logger.Info("Retrying failed delete", "name", ns.Name, "attempt", attempt, "wait", wait, "error", err.Error())
+		if werr := sleepCtx(ctx, wait); werr != nil {
+			return errors.Join(err, werr)
+		}
+	}
+}
+
+func retryableDeleteError(err error, retryConflicts bool) bool {
+	return apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) || (retryConflicts && apierrors.IsConflict(err))
+}
+
+// shuttingDown reports whether ctx was cancelled, as on shutdown or when losing the leader
+// election, as opposed to timing out. Errors caused by it aren't counted as failures.
+func shuttingDown(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// deleteErrorReason classifies a failed delete for delete_errors_total, telling an RBAC problem
+// from API load. NotFound never gets here: it counts as already_gone.
+func deleteErrorReason(err error) string {
+	switch {
+	case apierrors.IsForbidden(err):
+		return "forbidden"
+	case apierrors.IsConflict(err):
+		return "conflict"
+	case apierrors.IsServerTimeout(err), apierrors.IsTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "other"
+	}
+}
+
+// eventf records an event on obj, unless there is no Recorder or the sweeper is audit-only.
+func (s *NamespaceSweeper) eventf(obj runtime.Object, eventType, reason, messageFmt string, args ...any) {
+	if s.Recorder == nil || s.AuditOnly {
+		return
+	}
+	s.Recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+// byController is appended to deletion event messages to attribute them to this replica.
+func (s *NamespaceSweeper) byController() string {
+	if s.ControllerID == "" {
+		return ""
+	}
+	return " by " + s.ControllerID
+}
+
+// deleteSpreadWindow is how long this sweep's deletions may be spread over: DeleteSpread, cut
+// short so it ends before the next sweep, which is due Interval after start.
+func (s *NamespaceSweeper) deleteSpreadWindow(start time.Time) time.Duration {
+	if s.DeleteSpread <= 0 {
+		return 0
+	}
+	if s.Interval <= 0 {
+		return s.DeleteSpread
+	}
+	return max(min(s.DeleteSpread, s.Interval-time.Since(start)), 0)
+}
+
+// deleteLimit is how many of n expired namespaces this sweep may delete.
+func (s *NamespaceSweeper) deleteLimit(n int) int {
+	limit := n
+	if s.DeletePercentOfExpired > 0 && s.DeletePercentOfExpired < 100 {
+		limit = int(math.Ceil(float64(n) * s.DeletePercentOfExpired / 100))
+	}
+	return limit
+}
+
+// freshCopy returns the namespace as the API server has it now, or nil if it is gone or
+// has changed since ns was listed.
+func (s *NamespaceSweeper) freshCopy(ctx context.Context, ns *corev1.Namespace) (*corev1.Namespace, error) {
+	fresh := &corev1.Namespace{}
+	if err := s.APIReader.Get(ctx, client.ObjectKeyFromObject(ns), fresh); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if fresh.UID != ns.UID || fresh.ResourceVersion != ns.ResourceVersion {
+		return nil, nil
+	}
+	return fresh, nil
+}
+
+func (s *NamespaceSweeper) createdInWindow(created time.Time) bool {
+	if !s.CreatedAfter.IsZero() && !created.After(s.CreatedAfter) {
+		return false
+	}
+	if !s.CreatedBefore.IsZero() && !created.Before(s.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// cacheSyncTimeout is CacheSyncTimeout with its default applied.
+func (s *NamespaceSweeper) cacheSyncTimeout() time.Duration {
+	if s.CacheSyncTimeout <= 0 {
+		return 2 * time.Minute
+	}
+	return s.CacheSyncTimeout
+}
+
+// waitForCache polls CacheHealthy until it reports true or CacheSyncTimeout passes.
+func (s *NamespaceSweeper) waitForCache(ctx context.Context) bool {
+	if s.CacheHealthy == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cacheSyncTimeout())
+	defer cancel()
+	err := wait.PollUntilContextCancel(ctx, 500*time.Millisecond, true, func(ctx context.Context) (bool, error) {
+		return s.CacheHealthy(ctx), nil
+	})
+	return err == nil
+}
+
+// deletionBlocker runs the sweep-wide safety checks, updating their gauges, and returns why
+// deletions must be skipped this sweep, or "" if they may proceed.
+func (s *NamespaceSweeper) deletionBlocker(ctx context.Context, logger logr.Logger) string {
+	var reasons []string
+
+	if s.CacheHealthy != nil {
+		if s.CacheHealthy(ctx) {
+			cacheHealthy.Set(1)
+		} else {
+			cacheHealthy.Set(0)
+			logger.Error(nil, "Namespace cache is not synced, refusing to delete based on a possibly partial list")
+			reasons = append(reasons, "cache not synced")
+		}
+	}
+
+	if s.killSwitchEngaged() {
+		killSwitchEngaged.Set(1)
+		logger.Info("Kill switch engaged, no namespaces will be deleted", "file", s.KillSwitchFile)
+		reasons = append(reasons, "kill switch engaged")
+	} else {
+		killSwitchEngaged.Set(0)
+	}
+
+	if s.PressureAware {
+		fraction, err := s.memoryPressureFraction(ctx)
+		if err != nil {
+			logger.Error(err, "Failed to check node pressure, deleting as usual")
+		} else {
+			nodePressureFraction.Set(fraction)
+			if fraction >= s.PressureNodeFraction {
+				logger.Info("Nodes under memory pressure, pausing deletions", "fraction", fraction, "threshold", s.PressureNodeFraction)
+				reasons = append(reasons, "node memory pressure")
 			}
 		}
 	}
+
+	return strings.Join(reasons, ", ")
+}
+
+// memoryPressureFraction returns the fraction of nodes with a true MemoryPressure condition.
+func (s *NamespaceSweeper) memoryPressureFraction(ctx context.Context) (float64, error) {
+	var nodes corev1.NodeList
+	if err := s.Client.List(ctx, &nodes); err != nil {
+		return 0, err
+	}
+	if len(nodes.Items) == 0 {
+		return 0, nil
+	}
+	pressured := 0
+	for i := range nodes.Items {
+		for _, cond := range nodes.Items[i].Status.Conditions {
+			if cond.Type == corev1.NodeMemoryPressure && cond.Status == corev1.ConditionTrue {
+				pressured++
+				break
+			}
+		}
+	}
+	return float64(pressured) / float64(len(nodes.Items)), nil
+}
+
+// killSwitchEngaged re-stats the kill switch file so toggling it takes effect next sweep.
+func (s *NamespaceSweeper) killSwitchEngaged() bool {
+	if s.KillSwitchFile == "" {
+		return false
+	}
+	_, err := os.Stat(s.KillSwitchFile)
+	return err == nil
+}
+
+// pendingLoadBalancer returns the name of a LoadBalancer Service in the namespace that has no
+// ingress yet or is being torn down, or "" if there is none.
+func (s *NamespaceSweeper) pendingLoadBalancer(ctx context.Context, namespace string) (string, error) {
+	var svcs corev1.ServiceList
+	if err := s.Client.List(ctx, &svcs, client.InNamespace(namespace)); err != nil {
+		return "", err
+	}
+	for i := range svcs.Items {
+		svc := &svcs.Items[i]
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		if svc.DeletionTimestamp != nil || len(svc.Status.LoadBalancer.Ingress) == 0 {
+			return svc.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// futureTimestampSlack tolerates creation timestamps slightly ahead of now, from clock skew that
+// ClockSkew doesn't correct.
+const futureTimestampSlack = time.Minute
+
+// invalidCreationTimestamp reports why ns's creation timestamp can't be aged against now, e.g.
+// because a proxy stripped or rewrote it: "zero-timestamp" or "future-timestamp".
+func invalidCreationTimestamp(ns *corev1.Namespace, now time.Time) (string, bool) {
+	switch created := ns.CreationTimestamp.Time; {
+	case created.IsZero():
+		return "zero-timestamp", true
+	case created.Sub(now) > futureTimestampSlack:
+		return "future-timestamp", true
+	}
+	return "", false
+}
+
+// dryRunFor applies the per-namespace dry-run and enforce annotations on top of the global DryRun
+// and the dryRun of the namespace's SweepPolicy. dry-run=true wins, as the safe choice.
+func (s *NamespaceSweeper) dryRunFor(ns *corev1.Namespace, policyDryRun bool) bool {
+	if dryRun, err := strconv.ParseBool(ns.Annotations[AnnotationDryRun]); err == nil && dryRun {
+		return true
+	}
+	if enforce, err := strconv.ParseBool(ns.Annotations[AnnotationEnforce]); err == nil {
+		return !enforce
+	}
+	return s.DryRun || policyDryRun
+}
+
+// annotation example: preview-sweeper.maxsauce.com/ttl="4h", "30m", "2h45m", "69" (int = hours), "7d", "2w"
+func resolveTTL(annotations map[string]string, defaultTTL time.Duration) (time.Duration, string) {
+	if d, ok := parseDurationAnnotation(annotations[AnnotationTTL]); ok {
+		return d, "annotation"
+	}
 	return defaultTTL, "default"
 }
 
-// copied from the internets
+// emptyTTLAnnotation reports whether the ttl annotation is present but blank, which
+// resolveTTL treats like a missing one.
+func emptyTTLAnnotation(annotations map[string]string) bool {
+	raw, ok := annotations[AnnotationTTL]
+	return ok && strings.TrimSpace(raw) == ""
+}
+
+// parseDurationAnnotation parses a ttl-style annotation value: a Go duration, bare hours, or
+// whole days or weeks with a "d" or "w" suffix.
+func parseDurationAnnotation(raw string) (time.Duration, bool) {
+	val := strings.TrimSpace(raw)
+	if val == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(val); err == nil {
+		return d, true
+	}
+	unit := time.Hour
+	switch {
+	case strings.HasSuffix(val, "d"):
+		val, unit = strings.TrimSuffix(val, "d"), 24*time.Hour
+	case strings.HasSuffix(val, "w"):
+		val, unit = strings.TrimSuffix(val, "w"), 7*24*time.Hour
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n > math.MaxInt64/int(unit) || n < math.MinInt64/int(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// resolveGrace returns how long an expired namespace is kept, with a DeletionPending warning,
+// before it is deleted: a valid, non-negative grace annotation capped at MaxGracePeriod, otherwise
+// GracePeriod.
+func (s *NamespaceSweeper) resolveGrace(annotations map[string]string) (time.Duration, string) {
+	d, ok := parseDurationAnnotation(annotations[AnnotationGrace])
+	if !ok || d < 0 {
+		return s.GracePeriod, "default"
+	}
+	if s.MaxGracePeriod > 0 && d > s.MaxGracePeriod {
+		return s.MaxGracePeriod, "annotation"
+	}
+	return d, "annotation"
+}
+
+// tracer returns Tracer, or a no-op tracer when tracing is off.
+func (s *NamespaceSweeper) tracer() trace.Tracer {
+	if s.Tracer == nil {
+		return noop.NewTracerProvider().Tracer("")
+	}
+	return s.Tracer
+}
+
+// observeWithTraceExemplar attaches the trace ID of the span in ctx as an exemplar, so a slow
+// sweep can be followed to its trace. Without an active span it is a plain Observe.
+func observeWithTraceExemplar(ctx context.Context, h prometheus.Histogram, v float64) {
+	sc := trace.SpanContextFromContext(ctx)
+	eo, ok := h.(prometheus.ExemplarObserver)
+	if !sc.IsValid() || !ok {
+		h.Observe(v)
+		return
+	}
+	eo.ObserveWithExemplar(v, prometheus.Labels{"trace_id": sc.TraceID().String()})
+}
+
+// nextTick returns the first anchor+k*interval slot after now.
+// Slots missed by an overrunning sweep are skipped, not run back to back.
+func nextTick(anchor time.Time, interval time.Duration, now time.Time) time.Time {
+	if now.Before(anchor) {
+		return anchor
+	}
+	return anchor.Add((now.Sub(anchor)/interval + 1) * interval)
+}
+
+// nextSweep returns the slot to sweep after the one scheduled at slot, and when to fire for it
+// with JitterPercent applied. The slot follows the scheduled one, not now: a sweep jittered early
+// finishes before its own slot, and scheduling from now would run that slot again.
+func (s *NamespaceSweeper) nextSweep(anchor, slot, now time.Time) (next, fireAt time.Time) {
+	next = slot.Add(s.Interval)
+	if t := nextTick(anchor, s.Interval, now); t.After(next) {
+		next = t
+	}
+	return next, next.Add(s.withJitter(s.Interval, s.JitterPercent) - s.Interval)
+}
+
+// withJitter returns base offset by a uniformly random amount within ±pct/2 of it.
 func (s *NamespaceSweeper) withJitter(base time.Duration, pct float64) time.Duration {
 	if pct <= 0 {
 		return base
 	}
-	// simple, deterministic jitter based on current time (no rand needed)
-	nanos := time.Now().UnixNano()
-	sign := int64(1)
-	if nanos&1 == 1 {
-		sign = -1
+	s.jitterMu.Lock()
+	defer s.jitterMu.Unlock()
+	if s.jitterRand == nil {
+		// Seeded per sweeper, so replicas started together don't jitter in lockstep
+		s.jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
 	}
-	delta := time.Duration(float64(base) * pct)
-	return base + time.Duration(sign)*delta/2
+	return base + time.Duration((s.jitterRand.Float64()-0.5)*pct*float64(base))
 }
@@ -9,12 +9,14 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
-	labelPreview   = "preview-sweeper.maxsauce.com/enabled"
-	annotationHold = "preview-sweeper.maxsauce.com/hold"
+	labelPreview     = "preview-sweeper.maxsauce.com/enabled"
+	annotationHold   = "preview-sweeper.maxsauce.com/hold"
+	annotationIgnore = "preview-sweeper.maxsauce.com/ignore"
 )
 
 var _ = Describe("NamespaceSweeper", func() {
@@ -132,4 +134,56 @@ var _ = Describe("NamespaceSweeper", func() {
 			return cur.DeletionTimestamp == nil
 		}).Should(BeTrue(), "held preview namespace must not be deleted while hold=true")
 	})
+
+	It("does NOT delete preview namespaces with the ignore annotation (even past TTL)", func() {
+		ns := &corev1.Namespace{}
+		ns.Name = "preview-ignored-1"
+		ns.Labels = map[string]string{labelPreview: "true"}
+		ns.Annotations = map[string]string{annotationIgnore: "true"}
+
+		By("creating an ignored preview namespace")
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+		// Let it age past TTL and allow a couple of sweeps to run
+		time.Sleep(testTTL + 2*testSweepEvery)
+
+		By("ensuring it is never marked for deletion")
+		Consistently(func() bool {
+			cur := &corev1.Namespace{}
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: ns.Name}, cur)
+			if apierrors.IsNotFound(err) {
+				return false
+			}
+			Expect(err).NotTo(HaveOccurred())
+			return cur.DeletionTimestamp == nil
+		}).Should(BeTrue(), "ignored preview namespace must never be deleted")
+	})
+
+	It("leaves terminating namespaces out of a List with the status.phase field selector", func() {
+		selector := map[string]string{"test.preview-sweeper/phase-selector": "true"}
+		live := &corev1.Namespace{}
+		live.Name = "phase-selector-live"
+		live.Labels = selector
+		gone := &corev1.Namespace{}
+		gone.Name = "phase-selector-gone"
+		gone.Labels = selector
+
+		Expect(k8sClient.Create(ctx, live)).To(Succeed())
+		Expect(k8sClient.Create(ctx, gone)).To(Succeed())
+		By("deleting one; without a namespace controller in envtest it stays Terminating")
+		Expect(k8sClient.Delete(ctx, gone)).To(Succeed())
+
+		Eventually(func() []string {
+			var list corev1.NamespaceList
+			Expect(k8sManager.GetAPIReader().List(ctx, &list,
+				client.MatchingLabels(selector),
+				client.MatchingFieldsSelector{Selector: fields.OneTermNotEqualSelector("status.phase", "Terminating")},
+			)).To(Succeed())
+			var names []string
+			for _, ns := range list.Items {
+				names = append(names, ns.Name)
+			}
+			return names
+		}).Should(ConsistOf(live.Name))
+	})
 })
@@ -0,0 +1,2060 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	sweeperv1 "github.com/seekin4u/preview-sweeper/api/v1"
+)
+
+// These tests run against a fake client, so they don't need envtest binaries.
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = sweeperv1.AddToScheme(scheme)
+	return scheme
+}
+
+// previewNS returns a labeled preview namespace created `age` ago.
+func previewNS(name string, age time.Duration, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		Labels:            map[string]string{LabelPreview: "true"},
+		Annotations:       annotations,
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+	}}
+}
+
+func newFakeClient(funcs *interceptor.Funcs, objs ...client.Object) client.WithWatch {
+	b := fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(objs...)
+	if funcs != nil {
+		b = b.WithInterceptorFuncs(*funcs)
+	}
+	return b.Build()
+}
+
+// isDeleted reports whether the namespace is gone from the fake client.
+func isDeleted(ctx context.Context, c client.Client, name string) bool {
+	err := c.Get(ctx, client.ObjectKey{Name: name}, &corev1.Namespace{})
+	return apierrors.IsNotFound(err)
+}
+
+func TestStartGivesUpAfterConsecutiveListErrors(t *testing.T) {
+	g := NewWithT(t)
+
+	failList := interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			return errors.New("token expired")
+		},
+	}
+	s := &NamespaceSweeper{
+		Client:                   newFakeClient(&failList),
+		TTL:                      time.Hour,
+		Interval:                 10 * time.Millisecond,
+		MaxConsecutiveListErrors: 3,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := s.Start(ctx)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(s.consecutiveListErrors).To(Equal(3))
+}
+
+func TestStartResetsSweepGaugesOnExit(t *testing.T) {
+	g := NewWithT(t)
+
+	s := &NamespaceSweeper{
+		Client:          newFakeClient(nil, previewNS("preview-young", time.Minute, nil)),
+		TTL:             time.Hour,
+		Interval:        10 * time.Millisecond,
+		CountdownWindow: 2 * time.Hour,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Start(ctx) }()
+
+	g.Eventually(func() float64 { return testutil.ToFloat64(lastCandidates) }, 5*time.Second).Should(Equal(1.0))
+	g.Expect(testutil.CollectAndCount(timeToDeletion)).To(Equal(1))
+
+	// Losing leadership cancels ctx
+	cancel()
+	g.Eventually(done, 5*time.Second).Should(Receive(BeNil()))
+	g.Expect(testutil.ToFloat64(lastScanned)).To(BeZero())
+	g.Expect(testutil.ToFloat64(lastCandidates)).To(BeZero())
+	g.Expect(testutil.CollectAndCount(timeToDeletion)).To(BeZero())
+}
+
+func TestSuccessfulListResetsListErrorBudget(t *testing.T) {
+	g := NewWithT(t)
+
+	s := &NamespaceSweeper{
+		Client:                   newFakeClient(nil),
+		TTL:                      time.Hour,
+		MaxConsecutiveListErrors: 3,
+		consecutiveListErrors:    2,
+	}
+	s.SweepOnce(context.Background())
+	g.Expect(s.consecutiveListErrors).To(BeZero())
+}
+
+func TestRequireAnnotationsGate(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-with-pr", 2*time.Hour, map[string]string{"preview-sweeper.maxsauce.com/pr": "42"}),
+		previewNS("preview-without-pr", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{
+		Client:             c,
+		TTL:                time.Hour,
+		RequireAnnotations: []string{"preview-sweeper.maxsauce.com/pr"},
+	}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-with-pr")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-without-pr")).To(BeFalse())
+	g.Expect(testutil.ToFloat64(lastMissingAnnotation)).To(Equal(1.0))
+}
+
+func TestNextTickKeepsFixedCadence(t *testing.T) {
+	g := NewWithT(t)
+	anchor := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	interval := 10 * time.Minute
+
+	// A quick sweep keeps the next slot
+	g.Expect(nextTick(anchor, interval, anchor.Add(30*time.Second))).To(Equal(anchor.Add(10 * time.Minute)))
+
+	// A sweep that overran 2.5 intervals skips the missed slots instead of drifting
+	g.Expect(nextTick(anchor, interval, anchor.Add(25*time.Minute))).To(Equal(anchor.Add(30 * time.Minute)))
+
+	// Landing exactly on a slot schedules the following one
+	g.Expect(nextTick(anchor, interval, anchor.Add(20*time.Minute))).To(Equal(anchor.Add(30 * time.Minute)))
+
+	// Before the anchor the anchor itself is next
+	g.Expect(nextTick(anchor, interval, anchor.Add(-time.Minute))).To(Equal(anchor))
+}
+
+func TestNextSweepWithJitter(t *testing.T) {
+	g := NewWithT(t)
+	anchor := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	interval := time.Hour
+	s := &NamespaceSweeper{Interval: interval, JitterPercent: 1}
+
+	// Sweeps finishing right when they fire, often before their slot, still advance one slot each
+	slot, now := anchor, anchor
+	early := 0
+	for k := 1; k <= 200; k++ {
+		next, fireAt := s.nextSweep(anchor, slot, now)
+		g.Expect(next).To(Equal(anchor.Add(time.Duration(k) * interval)))
+		g.Expect(fireAt.Sub(next).Abs()).To(BeNumerically("<=", interval/2))
+		if fireAt.Before(next) {
+			early++
+		}
+		slot, now = next, fireAt
+	}
+	g.Expect(early).To(BeNumerically(">", 50))
+
+	// An overrunning sweep still skips the slots it missed
+	next, _ := s.nextSweep(anchor, anchor, anchor.Add(150*time.Minute))
+	g.Expect(next).To(Equal(anchor.Add(3 * time.Hour)))
+}
+
+func TestEnforceAnnotationOverridesDryRun(t *testing.T) {
+	cases := []struct {
+		name        string
+		dryRun      bool
+		enforce     string
+		wantDeleted bool
+	}{
+		{"dry-run, enforce=true deletes", true, "true", true},
+		{"dry-run, enforce=false keeps", true, "false", false},
+		{"enforcing, enforce=true deletes", false, "true", true},
+		{"enforcing, enforce=false keeps", false, "false", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			c := newFakeClient(nil, previewNS("preview-enforce", 2*time.Hour, map[string]string{AnnotationEnforce: tc.enforce}))
+			s := &NamespaceSweeper{Client: c, TTL: time.Hour, DryRun: tc.dryRun}
+			s.SweepOnce(ctx)
+
+			g.Expect(isDeleted(ctx, c, "preview-enforce")).To(Equal(tc.wantDeleted))
+		})
+	}
+}
+
+func TestDryRunAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-dry", 2*time.Hour, map[string]string{AnnotationDryRun: "true"}),
+		previewNS("preview-dry-enforced", 2*time.Hour, map[string]string{AnnotationDryRun: "true", AnnotationEnforce: "true"}),
+		previewNS("preview-dry-false", 2*time.Hour, map[string]string{AnnotationDryRun: "false"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	before := testutil.ToFloat64(deletedTotal.WithLabelValues("dry_run"))
+	report, _ := s.evaluate(ctx, c)
+	got := map[string]Disposition{}
+	for _, e := range report.Namespaces {
+		got[e.Namespace] = e.Disposition
+	}
+	g.Expect(got).To(HaveKeyWithValue("preview-dry", DispositionWouldDelete))
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-dry")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-dry-enforced")).To(BeFalse(), "dry-run beats enforce")
+	g.Expect(isDeleted(ctx, c, "preview-dry-false")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(deletedTotal.WithLabelValues("dry_run"))).To(Equal(before + 2))
+}
+
+func TestCandidatesByTTLSource(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	c := newFakeClient(nil,
+		previewNS("preview-default-1", time.Minute, nil),
+		previewNS("preview-default-2", time.Minute, map[string]string{AnnotationTTL: "soon"}),
+		previewNS("preview-annotated", time.Minute, map[string]string{AnnotationTTL: "4h"}),
+		previewNS("preview-policy", time.Minute, map[string]string{AnnotationPolicy: "long"}),
+		previewNS("preview-expires", time.Minute, map[string]string{AnnotationExpiresAt: until}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Policies: map[string]time.Duration{"long": 48 * time.Hour}}
+	s.SweepOnce(ctx)
+
+	bySource := func() map[string]float64 {
+		got := map[string]float64{}
+		for _, src := range []string{"default", "annotation", "sweeppolicy", "expires-at"} {
+			got[src] = testutil.ToFloat64(candidatesByTTLSource.WithLabelValues(src))
+		}
+		return got
+	}
+	g.Expect(bySource()).To(Equal(map[string]float64{"default": 2, "annotation": 2, "sweeppolicy": 0, "expires-at": 1}))
+
+	// A source no candidate uses any more drops to 0
+	g.Expect(c.Delete(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "preview-expires"}})).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(bySource()).To(Equal(map[string]float64{"default": 2, "annotation": 2, "sweeppolicy": 0, "expires-at": 0}))
+}
+
+func TestTTLChangeDetection(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	ns := previewNS("preview-ttl-churn", time.Minute, map[string]string{AnnotationTTL: "2h"})
+	ns.UID = "uid-ttl-churn"
+	c := newFakeClient(nil, ns)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec}
+
+	before := testutil.ToFloat64(ttlChangesTotal)
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(ttlChangesTotal)).To(Equal(before), "first sighting is not a change")
+
+	cur := &corev1.Namespace{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: ns.Name}, cur)).To(Succeed())
+	cur.Annotations[AnnotationTTL] = "4h"
+	g.Expect(c.Update(ctx, cur)).To(Succeed())
+
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(ttlChangesTotal)).To(Equal(before + 1))
+	g.Expect(rec.Events).To(Receive(ContainSubstring("TTLChanged")))
+
+	// Namespaces that disappear are dropped from the tracking map
+	g.Expect(c.Delete(ctx, cur)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(s.lastTTLs).To(BeEmpty())
+}
+
+func TestBlockOnPendingLB(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	lbService := func(namespace string, ingress bool) *corev1.Service {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		}
+		if ingress {
+			svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+		}
+		return svc
+	}
+
+	c := newFakeClient(nil,
+		previewNS("preview-lb-pending", 2*time.Hour, nil), lbService("preview-lb-pending", false),
+		previewNS("preview-lb-ready", 2*time.Hour, nil), lbService("preview-lb-ready", true),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, BlockOnPendingLB: true}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-lb-pending")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-lb-ready")).To(BeTrue())
+	g.Expect(rec.Events).To(Receive(ContainSubstring("DeferredPendingLB")))
+}
+
+func TestKillSwitchFile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	killFile := filepath.Join(t.TempDir(), "kill")
+	g.Expect(os.WriteFile(killFile, []byte("on"), 0o600)).To(Succeed())
+
+	c := newFakeClient(nil, previewNS("preview-kill", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, KillSwitchFile: killFile}
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-kill")).To(BeFalse())
+	g.Expect(testutil.ToFloat64(killSwitchEngaged)).To(Equal(1.0))
+
+	g.Expect(os.Remove(killFile)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-kill")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(killSwitchEngaged)).To(BeZero())
+}
+
+func TestMatchAnnotations(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	m, err := ParseAnnotationMatcher("git-branch=^(feature|bugfix)/")
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = ParseAnnotationMatcher("git-branch=(")
+	g.Expect(err).To(HaveOccurred())
+	_, err = ParseAnnotationMatcher("no-equals-sign")
+	g.Expect(err).To(HaveOccurred())
+
+	c := newFakeClient(nil,
+		previewNS("preview-feature", 2*time.Hour, map[string]string{"git-branch": "feature/login"}),
+		previewNS("preview-main", 2*time.Hour, map[string]string{"git-branch": "main"}),
+		previewNS("preview-no-branch", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, MatchAnnotations: []AnnotationMatcher{m}}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-feature")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-main")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-no-branch")).To(BeFalse())
+	g.Expect(testutil.ToFloat64(lastAnnotationMismatch)).To(Equal(2.0))
+}
+
+func TestShutdownSummary(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-sum-1", 2*time.Hour, nil),
+		previewNS("preview-sum-2", 2*time.Hour, map[string]string{AnnotationTTL: "1h"}),
+		previewNS("preview-sum-3", 2*time.Hour, map[string]string{AnnotationEnforce: "false"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	var empty bytes.Buffer
+	s.writeSummary(&empty)
+	g.Expect(empty.String()).To(ContainSubstring("no namespace deletions"))
+
+	s.SweepOnce(ctx)
+
+	var out bytes.Buffer
+	s.writeSummary(&out)
+	g.Expect(out.String()).To(MatchRegexp(`annotation\s+deleted\s+1`))
+	g.Expect(out.String()).To(MatchRegexp(`default\s+deleted\s+1`))
+	g.Expect(out.String()).To(MatchRegexp(`default\s+dry_run\s+1`))
+}
+
+func TestCreationWindow(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-before-window", 72*time.Hour, nil),
+		previewNS("preview-in-window", 36*time.Hour, nil),
+		previewNS("preview-after-window", 2*time.Hour, nil),
+	)
+	now := time.Now()
+	s := &NamespaceSweeper{
+		Client:        c,
+		TTL:           time.Hour,
+		CreatedAfter:  now.Add(-48 * time.Hour),
+		CreatedBefore: now.Add(-24 * time.Hour),
+	}
+	s.SweepOnce(ctx)
+
+	// all three are expired, only the one inside the window goes
+	g.Expect(isDeleted(ctx, c, "preview-before-window")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-in-window")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-after-window")).To(BeFalse())
+}
+
+func TestPressureAwarePausesDeletion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	node := func(name string, pressure corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeMemoryPressure, Status: pressure},
+			}},
+		}
+	}
+	stressed := node("node-b", corev1.ConditionTrue)
+	c := newFakeClient(nil,
+		previewNS("preview-pressure", 2*time.Hour, nil),
+		node("node-a", corev1.ConditionFalse), stressed,
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, PressureAware: true, PressureNodeFraction: 0.5}
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-pressure")).To(BeFalse())
+	g.Expect(testutil.ToFloat64(nodePressureFraction)).To(Equal(0.5))
+
+	stressed.Status.Conditions[0].Status = corev1.ConditionFalse
+	g.Expect(c.Status().Update(ctx, stressed)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-pressure")).To(BeTrue())
+}
+
+func TestSweepDurationExemplar(t *testing.T) {
+	g := NewWithT(t)
+
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "test_sweep_seconds"})
+	observeWithTraceExemplar(context.Background(), h, 0.5)
+
+	traceID := trace.TraceID{0x01, 0x02}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{0x03}})
+	observeWithTraceExemplar(trace.ContextWithSpanContext(context.Background(), sc), h, 1.5)
+
+	m := &dto.Metric{}
+	g.Expect(h.Write(m)).To(Succeed())
+	g.Expect(m.GetHistogram().GetSampleCount()).To(Equal(uint64(2)))
+
+	var exemplars []*dto.Exemplar
+	for _, b := range m.GetHistogram().GetBucket() {
+		if b.GetExemplar() != nil {
+			exemplars = append(exemplars, b.GetExemplar())
+		}
+	}
+	g.Expect(exemplars).To(HaveLen(1))
+	g.Expect(exemplars[0].GetLabel()[0].GetValue()).To(Equal(traceID.String()))
+}
+
+func TestSweepSpans(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	c := newFakeClient(nil, previewNS("preview-old", 2*time.Hour, nil), previewNS("preview-new", time.Minute, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Tracer: tp.Tracer("test")}
+	s.SweepOnce(ctx)
+
+	spans := rec.Ended()
+	g.Expect(spans).To(HaveLen(2))
+	del, sweep := spans[0], spans[1]
+	g.Expect(sweep.Name()).To(Equal("Sweep"))
+	g.Expect(del.Name()).To(Equal("DeleteNamespace"))
+	g.Expect(del.Parent().SpanID()).To(Equal(sweep.SpanContext().SpanID()))
+	g.Expect(del.Attributes()).To(ContainElement(attribute.String("namespace", "preview-old")))
+
+	// The sweep's duration links to its trace
+	m := &dto.Metric{}
+	g.Expect(sweepDuration.Write(m)).To(Succeed())
+	var traceIDs []string
+	for _, b := range m.GetHistogram().GetBucket() {
+		for _, l := range b.GetExemplar().GetLabel() {
+			traceIDs = append(traceIDs, l.GetValue())
+		}
+	}
+	g.Expect(traceIDs).To(ContainElement(sweep.SpanContext().TraceID().String()))
+}
+
+func TestAPIReaderGuardsStaleDeletes(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// The API server rejects deletes whose resourceVersion precondition is stale
+	api := newFakeClient(nil,
+		previewNS("preview-unchanged", 2*time.Hour, nil),
+		previewNS("preview-held-just-now", 2*time.Hour, nil),
+	)
+	var seenPreconditions int
+	rejectStale := interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			do := &client.DeleteOptions{}
+			do.ApplyOptions(opts)
+			cur := &corev1.Namespace{}
+			if err := api.Get(ctx, client.ObjectKeyFromObject(obj), cur); err != nil {
+				return err
+			}
+			if do.Preconditions != nil && do.Preconditions.ResourceVersion != nil {
+				seenPreconditions++
+				if *do.Preconditions.ResourceVersion != cur.ResourceVersion {
+					return apierrors.NewConflict(corev1.Resource("namespaces"), obj.GetName(), errors.New("stale"))
+				}
+			}
+			return api.Delete(ctx, cur)
+		},
+	}
+
+	// The cache still holds both namespaces as listed before one of them was put on hold
+	var cachedObjs []client.Object
+	for _, name := range []string{"preview-unchanged", "preview-held-just-now"} {
+		cur := &corev1.Namespace{}
+		g.Expect(api.Get(ctx, client.ObjectKey{Name: name}, cur)).To(Succeed())
+		cachedObjs = append(cachedObjs, cur.DeepCopy())
+	}
+	cached := newFakeClient(&rejectStale, cachedObjs...)
+
+	held := &corev1.Namespace{}
+	g.Expect(api.Get(ctx, client.ObjectKey{Name: "preview-held-just-now"}, held)).To(Succeed())
+	held.Annotations = map[string]string{AnnotationHold: "true"}
+	g.Expect(api.Update(ctx, held)).To(Succeed())
+
+	s := &NamespaceSweeper{Client: cached, APIReader: api, TTL: time.Hour}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, api, "preview-unchanged")).To(BeTrue())
+	g.Expect(isDeleted(ctx, api, "preview-held-just-now")).To(BeFalse())
+	g.Expect(seenPreconditions).To(Equal(1))
+}
+
+func TestProtectAnnotationBeatsEverything(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	const protect = "example.com/protected"
+	c := newFakeClient(nil,
+		previewNS("preview-protected-default-ttl", 30*24*time.Hour, map[string]string{protect: "true"}),
+		previewNS("preview-protected-short-ttl", 2*time.Hour, map[string]string{protect: "true", AnnotationTTL: "1s"}),
+		previewNS("preview-protected-enforced", 2*time.Hour, map[string]string{protect: "true", AnnotationEnforce: "true"}),
+		previewNS("preview-protect-false", 2*time.Hour, map[string]string{protect: "false"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, ProtectAnnotation: protect}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-protected-default-ttl")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-protected-short-ttl")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-protected-enforced")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-protect-false")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(protectedByAnnotation)).To(Equal(3.0))
+}
+
+func TestUnsyncedCacheBlocksDeletion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	synced := false
+	c := newFakeClient(nil, previewNS("preview-cache", 2*time.Hour, nil))
+	s := &NamespaceSweeper{
+		Client:       c,
+		TTL:          time.Hour,
+		CacheHealthy: func(context.Context) bool { return synced },
+	}
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-cache")).To(BeFalse())
+	g.Expect(testutil.ToFloat64(cacheHealthy)).To(BeZero())
+
+	synced = true
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-cache")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(cacheHealthy)).To(Equal(1.0))
+}
+
+func TestListTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	hangingList := interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	s := &NamespaceSweeper{Client: newFakeClient(&hangingList), TTL: time.Hour, ListTimeout: 50 * time.Millisecond}
+
+	done := make(chan struct{})
+	go func() {
+		s.SweepOnce(context.Background())
+		close(done)
+	}()
+	g.Eventually(done).Should(BeClosed())
+	g.Expect(s.consecutiveListErrors).To(Equal(1))
+}
+
+func TestSweepTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	hangingList := interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	s := &NamespaceSweeper{Client: newFakeClient(&hangingList), TTL: time.Hour, SweepTimeout: 50 * time.Millisecond}
+	before := testutil.ToFloat64(sweepTimeoutsTotal)
+
+	done := make(chan struct{})
+	go func() {
+		s.SweepOnce(context.Background())
+		close(done)
+	}()
+	g.Eventually(done).Should(BeClosed())
+	g.Expect(testutil.ToFloat64(sweepTimeoutsTotal)).To(Equal(before + 1))
+
+	// A sweep that finishes in time isn't counted.
+	s = &NamespaceSweeper{Client: newFakeClient(nil), TTL: time.Hour, SweepTimeout: time.Minute}
+	s.SweepOnce(context.Background())
+	g.Expect(testutil.ToFloat64(sweepTimeoutsTotal)).To(Equal(before + 1))
+}
+
+func TestDeletePercentOfExpired(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var objs []client.Object
+	for i := 1; i <= 8; i++ {
+		objs = append(objs, previewNS(fmt.Sprintf("preview-backlog-%d", i), time.Duration(i+1)*time.Hour, nil))
+	}
+	c := newFakeClient(nil, objs...)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, DeletePercentOfExpired: 25}
+	s.SweepOnce(ctx)
+
+	var left corev1.NamespaceList
+	g.Expect(c.List(ctx, &left)).To(Succeed())
+	g.Expect(left.Items).To(HaveLen(6))
+	// the two oldest went first
+	g.Expect(isDeleted(ctx, c, "preview-backlog-8")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-backlog-7")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(lastExpired)).To(Equal(8.0))
+	g.Expect(testutil.ToFloat64(lastDeleted)).To(Equal(2.0))
+
+	// a single expired namespace still drains
+	g.Expect(s.deleteLimit(1)).To(Equal(1))
+}
+
+func TestMaxDeletesPerSweep(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var objs []client.Object
+	for i := 1; i <= 5; i++ {
+		objs = append(objs, previewNS(fmt.Sprintf("preview-backlog-%d", i), time.Duration(i+1)*time.Hour, nil))
+	}
+	// The oldest, but dry-run by annotation, so it goes first without using up the cap
+	objs = append(objs, previewNS("preview-held-back", 10*time.Hour, map[string]string{AnnotationEnforce: "false"}))
+	c := newFakeClient(nil, objs...)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, MaxDeletesPerSweep: 2}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(res.Expired).To(Equal(6))
+	g.Expect(res.Deleted).To(Equal(2))
+	g.Expect(testutil.ToFloat64(lastExpired)).To(Equal(6.0))
+	g.Expect(testutil.ToFloat64(lastDeleted)).To(Equal(2.0))
+	g.Expect(isDeleted(ctx, c, "preview-held-back")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-backlog-5")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-backlog-4")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-backlog-3")).To(BeFalse())
+
+	// The next sweep picks up where this one stopped
+	res = s.SweepOnce(ctx)
+	g.Expect(res.Deleted).To(Equal(2))
+	g.Expect(isDeleted(ctx, c, "preview-backlog-2")).To(BeTrue())
+}
+
+func TestDeleteNowAnnotation(t *testing.T) {
+	cases := []struct {
+		name          string
+		annotations   map[string]string
+		overridesHold bool
+		wantDeleted   bool
+	}{
+		{"young namespace is deleted", map[string]string{AnnotationDeleteNow: "true"}, false, true},
+		{"hold wins by default", map[string]string{AnnotationDeleteNow: "true", AnnotationHold: "true"}, false, false},
+		{"delete-now can override hold", map[string]string{AnnotationDeleteNow: "true", AnnotationHold: "true"}, true, true},
+		{"protect always wins", map[string]string{AnnotationDeleteNow: "true", "example.com/protected": "true"}, true, false},
+		{"other values are ignored", map[string]string{AnnotationDeleteNow: "yes"}, false, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			c := newFakeClient(nil, previewNS("preview-delete-now", time.Minute, tc.annotations))
+			rec := record.NewFakeRecorder(10)
+			s := &NamespaceSweeper{
+				Client:                 c,
+				TTL:                    time.Hour,
+				Recorder:               rec,
+				ProtectAnnotation:      "example.com/protected",
+				DeleteNowOverridesHold: tc.overridesHold,
+			}
+			s.SweepOnce(ctx)
+
+			g.Expect(isDeleted(ctx, c, "preview-delete-now")).To(Equal(tc.wantDeleted))
+			if tc.wantDeleted {
+				g.Expect(rec.Events).To(Receive(ContainSubstring("DeleteRequested")))
+				g.Expect(rec.Events).To(Receive(Equal(fmt.Sprintf(
+					`Normal NamespaceCleanup Deleted namespace "preview-delete-now": deletion requested via the %s annotation`, AnnotationDeleteNow))))
+			}
+		})
+	}
+}
+
+func TestSourceStateAnnotation(t *testing.T) {
+	cases := []struct {
+		state       string
+		hold        bool
+		wantDeleted bool
+	}{
+		{"open", false, false},
+		{"closed", false, true},
+		{"merged", false, true},
+		{" Merged ", false, true},
+		{"", false, false},
+		{"archived", false, false},
+		{"closed", true, false},
+	}
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%q hold=%t", tc.state, tc.hold), func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			annotations := map[string]string{AnnotationSourceState: tc.state}
+			if tc.hold {
+				annotations[AnnotationHold] = "true"
+			}
+			c := newFakeClient(nil, previewNS("preview-pr", time.Minute, annotations))
+			rec := record.NewFakeRecorder(10)
+			s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec}
+			s.SweepOnce(ctx)
+
+			g.Expect(isDeleted(ctx, c, "preview-pr")).To(Equal(tc.wantDeleted))
+			if tc.wantDeleted {
+				g.Expect(rec.Events).To(Receive(ContainSubstring("SourceClosed")))
+			}
+		})
+	}
+}
+
+func TestResolveGrace(t *testing.T) {
+	s := &NamespaceSweeper{GracePeriod: 30 * time.Minute, MaxGracePeriod: 6 * time.Hour}
+	cases := []struct {
+		value   string
+		want    time.Duration
+		wantSrc string
+	}{
+		{"", 30 * time.Minute, "default"},
+		{"2h", 2 * time.Hour, "annotation"},
+		{"3", 3 * time.Hour, "annotation"},
+		{"0", 0, "annotation"},
+		{"soon", 30 * time.Minute, "default"},
+		{"-1h", 30 * time.Minute, "default"},
+		{"72h", 6 * time.Hour, "annotation"},
+	}
+	for _, tc := range cases {
+		got, src := s.resolveGrace(map[string]string{AnnotationGrace: tc.value})
+		if got != tc.want || src != tc.wantSrc {
+			t.Errorf("grace %q: got %s (%s), want %s (%s)", tc.value, got, src, tc.want, tc.wantSrc)
+		}
+	}
+
+	uncapped := &NamespaceSweeper{}
+	if got, _ := uncapped.resolveGrace(map[string]string{AnnotationGrace: "72h"}); got != 72*time.Hour {
+		t.Errorf("without a cap the annotation should apply, got %s", got)
+	}
+}
+
+func TestGracePeriodDefersDeletion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-in-grace", 90*time.Minute, nil),
+		previewNS("preview-past-grace", 3*time.Hour, nil),
+		previewNS("preview-short-grace", 90*time.Minute, map[string]string{AnnotationGrace: "10m"}),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, GracePeriod: time.Hour}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-in-grace")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-past-grace")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-short-grace")).To(BeTrue())
+	g.Expect(res.Expired).To(Equal(2))
+
+	var events []string
+	for len(rec.Events) > 0 {
+		events = append(events, <-rec.Events)
+	}
+	g.Expect(events).To(ContainElement(HavePrefix("Warning DeletionPending TTL 1h0m0s ran out")))
+}
+
+func TestGracePeriodOnlyDefersTTLExpiry(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-in-grace", 90*time.Minute, nil),
+		previewNS("preview-merged", 90*time.Minute, map[string]string{AnnotationSourceState: "merged"}),
+		previewNS("preview-delete-now", time.Minute, map[string]string{AnnotationDeleteNow: "true"}),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, GracePeriod: time.Hour}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-in-grace")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-merged")).To(BeTrue(), "source-state deletes right away")
+	g.Expect(isDeleted(ctx, c, "preview-delete-now")).To(BeTrue(), "delete-now deletes right away")
+
+	var pending int
+	for len(rec.Events) > 0 {
+		if strings.Contains(<-rec.Events, "DeletionPending") {
+			pending++
+		}
+	}
+	g.Expect(pending).To(Equal(1), "only the TTL-expired namespace is warned")
+}
+
+func TestInvalidGraceAnnotationIsIgnored(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-within-grace", 90*time.Minute, map[string]string{AnnotationGrace: "1h"}),
+		previewNS("preview-past-grace", 150*time.Minute, map[string]string{AnnotationGrace: "1h"}),
+		previewNS("preview-bad-grace", 90*time.Minute, map[string]string{AnnotationGrace: "a while"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	report, ok := s.evaluate(ctx, c)
+	g.Expect(ok).To(BeTrue())
+	for _, e := range report.Namespaces {
+		if e.Namespace == "preview-bad-grace" {
+			g.Expect(e.Problems).To(ConsistOf(ContainSubstring(`invalid ` + AnnotationGrace + ` annotation "a while"`)))
+		}
+	}
+
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-within-grace")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-past-grace")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-bad-grace")).To(BeTrue())
+}
+
+func TestSweepInProgressGauge(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var during float64
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			during = testutil.ToFloat64(sweepInProgress)
+			return c.List(ctx, list, opts...)
+		},
+	})
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	s.SweepOnce(ctx)
+
+	g.Expect(during).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(sweepInProgress)).To(BeZero())
+}
+
+func TestDeletionEventsCarryControllerID(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil, previewNS("preview-attributed", 2*time.Hour, nil))
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, ControllerID: "preview-sweeper-7d9f-abcde"}
+	s.SweepOnce(ctx)
+
+	g.Expect(rec.Events).To(Receive(And(
+		ContainSubstring("NamespaceCleanup"),
+		HaveSuffix("by preview-sweeper-7d9f-abcde"),
+	)))
+}
+
+func TestWaitForCache(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	polls := 0
+	s := &NamespaceSweeper{
+		CacheHealthy: func(context.Context) bool {
+			polls++
+			return polls >= 3
+		},
+		CacheSyncTimeout: 5 * time.Second,
+	}
+	g.Expect(s.waitForCache(ctx)).To(BeTrue())
+	g.Expect(polls).To(Equal(3))
+
+	never := &NamespaceSweeper{
+		CacheHealthy:     func(context.Context) bool { return false },
+		CacheSyncTimeout: 100 * time.Millisecond,
+	}
+	g.Expect(never.waitForCache(ctx)).To(BeFalse())
+
+	g.Expect((&NamespaceSweeper{}).waitForCache(ctx)).To(BeTrue(), "no readiness func means nothing to wait for")
+
+	// The default applies without overwriting the configured value
+	unset := &NamespaceSweeper{CacheHealthy: func(context.Context) bool { return true }}
+	g.Expect(unset.waitForCache(ctx)).To(BeTrue())
+	g.Expect(unset.CacheSyncTimeout).To(BeZero())
+	g.Expect(unset.cacheSyncTimeout()).To(Equal(2 * time.Minute))
+}
+
+func TestSystemMarker(t *testing.T) {
+	withLabels := func(name string, l map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: l}}
+	}
+	cases := []struct {
+		name    string
+		ns      *corev1.Namespace
+		markers []string
+		want    bool
+	}{
+		{"plain preview", withLabels("preview-a", map[string]string{corev1.LabelMetadataName: "preview-a"}), nil, false},
+		{"metadata.name mismatch", withLabels("preview-a", map[string]string{corev1.LabelMetadataName: "openshift-etcd"}), nil, true},
+		{"openshift run-level", withLabels("preview-a", map[string]string{"openshift.io/run-level": "0"}), nil, true},
+		{"monitoring=false is not a marker", withLabels("preview-a", map[string]string{"openshift.io/cluster-monitoring": "false"}), nil, false},
+		{"custom marker", withLabels("preview-a", map[string]string{"field.cattle.io/projectId": "p-sys"}), []string{"field.cattle.io/projectId"}, true},
+		{"custom markers replace defaults", withLabels("preview-a", map[string]string{"openshift.io/run-level": "0"}), []string{"example.com/system"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, got := systemMarker(tc.ns, tc.markers)
+			NewWithT(t).Expect(got).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestSecondsSinceLastDelete(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	lastDeleteNanos.Store(time.Now().Add(-time.Hour).UnixNano())
+	g.Expect(testutil.ToFloat64(secondsSinceLastDelete)).To(BeNumerically("~", 3600, 5))
+
+	c := newFakeClient(nil, previewNS("preview-since-delete", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	s.SweepOnce(ctx)
+
+	g.Expect(testutil.ToFloat64(secondsSinceLastDelete)).To(BeNumerically("<", 5))
+}
+
+func TestSkipGitOpsOwned(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	const tracking = "argocd.argoproj.io/tracking-id"
+	owned := previewNS("preview-argo", 2*time.Hour, nil)
+	owned.Labels[tracking] = "app:/Namespace:preview-argo"
+	c := newFakeClient(nil,
+		owned,
+		previewNS("preview-argo-enforced", 2*time.Hour, map[string]string{tracking: "app", AnnotationEnforce: "true"}),
+		previewNS("preview-unowned", 2*time.Hour, nil),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, SkipGitOpsOwned: true, GitOpsOwnershipKey: tracking}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-argo")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-argo-enforced")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-unowned")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(lastGitOpsOwned)).To(Equal(1.0))
+	g.Expect(rec.Events).To(Receive(ContainSubstring("SkippedGitOpsOwned")))
+}
+
+func TestExternalLiveness(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Query().Get("ns") {
+		case "preview-db-gone", "preview-db-gone-held":
+			_, _ = w.Write([]byte(`{"alive": false}`))
+		case "preview-db-slow":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"alive": false}`))
+		case "preview-db-broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"alive": true}`))
+		}
+	}))
+	defer srv.Close()
+
+	tmpl, err := ParseLivenessURL(srv.URL + "/alive?ns={{.Name | urlquery}}")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c := newFakeClient(nil,
+		previewNS("preview-db-gone", time.Minute, nil),
+		previewNS("preview-db-gone-held", time.Minute, map[string]string{AnnotationHold: "true"}),
+		previewNS("preview-db-alive", time.Minute, nil),
+		previewNS("preview-db-slow", time.Minute, nil),
+		previewNS("preview-db-broken", time.Minute, nil),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{
+		Client: c, TTL: time.Hour, Recorder: rec,
+		Liveness: &LivenessChecker{URL: tmpl, Timeout: 50 * time.Millisecond, CacheTTL: time.Minute},
+	}
+	errorsBefore := testutil.ToFloat64(livenessErrorsTotal)
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-db-gone")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-db-gone-held")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-db-alive")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-db-slow")).To(BeFalse(), "timeouts must count as alive")
+	g.Expect(isDeleted(ctx, c, "preview-db-broken")).To(BeFalse(), "errors must count as alive")
+	g.Expect(testutil.ToFloat64(livenessErrorsTotal) - errorsBefore).To(Equal(2.0))
+	g.Expect(rec.Events).To(Receive(ContainSubstring("ExternalResourceGone")))
+
+	// The answer for the alive namespace is cached; the failures are asked again
+	before := calls.Load()
+	s.SweepOnce(ctx)
+	g.Expect(calls.Load() - before).To(Equal(int32(2)))
+}
+
+func TestExternalLivenessUnreachable(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	tmpl, err := ParseLivenessURL(srv.URL + "/alive?ns={{.Name | urlquery}}")
+	g.Expect(err).NotTo(HaveOccurred())
+	var objs []client.Object
+	for i := range 20 {
+		objs = append(objs, previewNS(fmt.Sprintf("preview-db-%d", i), time.Minute, nil))
+	}
+	c := newFakeClient(nil, objs...)
+	s := &NamespaceSweeper{
+		Client: c, TTL: time.Hour,
+		Liveness: &LivenessChecker{URL: tmpl, Timeout: 50 * time.Millisecond},
+	}
+
+	// One timeout, not twenty
+	start := time.Now()
+	g.Expect(s.SweepOnce(ctx).Deleted).To(BeZero())
+	g.Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+	g.Expect(calls.Load()).To(Equal(int32(1)))
+
+	// The next sweep asks again
+	s.SweepOnce(ctx)
+	g.Expect(calls.Load()).To(Equal(int32(2)))
+}
+
+func TestCountdownSeriesLifecycle(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	timeToDeletion.Reset()
+
+	c := newFakeClient(nil,
+		previewNS("preview-soon", 50*time.Minute, nil),
+		previewNS("preview-later", 10*time.Minute, nil),
+		previewNS("preview-expired", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, CountdownWindow: 15 * time.Minute}
+	s.SweepOnce(ctx)
+
+	g.Expect(testutil.CollectAndCount(timeToDeletion)).To(Equal(1))
+	g.Expect(testutil.ToFloat64(timeToDeletion.WithLabelValues("preview-soon"))).To(BeNumerically("~", 600, 5))
+
+	// Extending the TTL moves the namespace out of the window, so its series goes away
+	var ns corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-soon"}, &ns)).To(Succeed())
+	ns.Annotations = map[string]string{AnnotationTTL: "4h"}
+	g.Expect(c.Update(ctx, &ns)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(testutil.CollectAndCount(timeToDeletion)).To(Equal(0))
+
+	// A namespace that expires and gets deleted loses its series too
+	s.TTL = 55 * time.Minute
+	s.CountdownWindow = time.Hour
+	s.SweepOnce(ctx)
+	g.Expect(testutil.CollectAndCount(timeToDeletion)).To(Equal(1)) // preview-later
+	s.TTL = 5 * time.Minute
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-later")).To(BeTrue())
+	g.Expect(testutil.CollectAndCount(timeToDeletion)).To(Equal(0))
+}
+
+func TestTrackSweepCount(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-flappy", 10*time.Minute, nil),
+		previewNS("preview-garbled", 10*time.Minute, map[string]string{AnnotationSweepCount: "many"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, TrackSweepCount: true}
+	count := func(name string) string {
+		var ns corev1.Namespace
+		g.Expect(c.Get(ctx, client.ObjectKey{Name: name}, &ns)).To(Succeed())
+		return ns.Annotations[AnnotationSweepCount]
+	}
+
+	s.SweepOnce(ctx)
+	g.Expect(count("preview-flappy")).To(Equal("1"))
+	s.SweepOnce(ctx)
+	g.Expect(count("preview-flappy")).To(Equal("2"))
+	g.Expect(count("preview-garbled")).To(Equal("2"))
+
+	s.DryRun = true
+	s.SweepOnce(ctx)
+	g.Expect(count("preview-flappy")).To(Equal("2"))
+}
+
+func TestWritesCarryFieldManager(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var managers []string
+	c := newFakeClient(&interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			po := &client.PatchOptions{}
+			po.ApplyOptions(opts)
+			managers = append(managers, po.FieldManager)
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}, previewNS("preview-a", 10*time.Minute, nil))
+
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, TrackSweepCount: true}
+	s.SweepOnce(ctx)
+	s.FieldManager = "sweeper-canary"
+	s.SweepOnce(ctx)
+	g.Expect(managers).To(Equal([]string{DefaultFieldManager, "sweeper-canary"}))
+}
+
+func TestDenyPrefixWinsOverEnablePrefix(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-prod-db", 2*time.Hour, nil),
+		previewNS("preview-feature-x", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, DenyPrefixes: []string{"preview-prod-"}}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-prod-db")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-feature-x")).To(BeTrue())
+	g.Expect(res.Candidates).To(Equal(1))
+	g.Expect(testutil.ToFloat64(protectedByAnnotation)).To(Equal(1.0))
+}
+
+func TestNamespacePrefixes(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("pr-123", 2*time.Hour, nil),
+		previewNS("preview-feature-x", 2*time.Hour, nil),
+		previewNS("review-feature-y", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Prefixes: []string{"pr-", "preview-"}}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "pr-123")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-feature-x")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "review-feature-y")).To(BeFalse())
+	g.Expect(res.Candidates).To(Equal(2))
+
+	// No prefixes falls back to the default rather than matching everything
+	c = newFakeClient(nil, previewNS("pr-123", 2*time.Hour, nil), previewNS("preview-feature-x", 2*time.Hour, nil))
+	s = &NamespaceSweeper{Client: c, TTL: time.Hour}
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "pr-123")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-feature-x")).To(BeTrue())
+}
+
+func TestLabelKey(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	forked := previewNS("preview-forked", 2*time.Hour, nil)
+	forked.Labels = map[string]string{"example.com/preview": "true"}
+	c := newFakeClient(nil, forked, previewNS("preview-upstream", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, LabelKey: "example.com/preview"}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(res.Scanned).To(Equal(1))
+	g.Expect(isDeleted(ctx, c, "preview-forked")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-upstream")).To(BeFalse())
+
+	g.Expect((&NamespaceSweeper{}).EnableLabel()).To(Equal(LabelPreview))
+	g.Expect(ValidateLabelKey("example.com/preview")).To(Succeed())
+	g.Expect(ValidateLabelKey("enabled")).To(Succeed())
+	for _, key := range []string{"", "example.com/", "Example_Com/preview", "has space", "a/b/c"} {
+		g.Expect(ValidateLabelKey(key)).NotTo(Succeed(), key)
+	}
+}
+
+func TestExpiresAtAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	c := newFakeClient(nil,
+		// Young, but its time is up
+		previewNS("preview-due", 5*time.Minute, map[string]string{AnnotationExpiresAt: past}),
+		// Past the TTL, but not its time yet
+		previewNS("preview-later", 5*time.Hour, map[string]string{AnnotationExpiresAt: future, AnnotationTTL: "1h"}),
+		previewNS("preview-invalid", 5*time.Hour, map[string]string{AnnotationExpiresAt: "tomorrow"}),
+		previewNS("preview-blank", 5*time.Hour, map[string]string{AnnotationExpiresAt: " "}),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-due")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-later")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-invalid")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-blank")).To(BeTrue())
+	var events []string
+	for len(rec.Events) > 0 {
+		events = append(events, <-rec.Events)
+	}
+	g.Expect(events).To(ContainElement(And(HavePrefix("Normal NamespaceCleanup"), ContainSubstring("(expires-at)"))))
+}
+
+func TestExcludeSelectorWinsOverEnableLabel(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	labeled := func(name string, extra map[string]string) *corev1.Namespace {
+		ns := previewNS(name, 2*time.Hour, nil)
+		for k, v := range extra {
+			ns.Labels[k] = v
+		}
+		return ns
+	}
+	unlabeled := previewNS("preview-not-enabled", 2*time.Hour, nil)
+	unlabeled.Labels = map[string]string{"protected": "false"}
+	c := newFakeClient(nil,
+		labeled("preview-protected", map[string]string{"protected": "true"}),
+		labeled("preview-unprotected", map[string]string{"protected": "false"}),
+		labeled("preview-plain", nil),
+		unlabeled,
+	)
+	sel, err := labels.Parse("protected=true")
+	g.Expect(err).NotTo(HaveOccurred())
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, ExcludeSelector: sel}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-protected")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-unprotected")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-plain")).To(BeTrue())
+	// The exclude selector never widens what the enable label selects
+	g.Expect(isDeleted(ctx, c, "preview-not-enabled")).To(BeFalse())
+	g.Expect(res.Candidates).To(Equal(2))
+	g.Expect(testutil.ToFloat64(protectedByAnnotation)).To(Equal(1.0))
+
+	// Set-based selectors work too
+	c = newFakeClient(nil, labeled("preview-a", map[string]string{"tier": "prod"}), labeled("preview-b", map[string]string{"tier": "dev"}))
+	s.Client = c
+	s.ExcludeSelector, err = labels.Parse("tier notin (dev)")
+	g.Expect(err).NotTo(HaveOccurred())
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-a")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-b")).To(BeTrue())
+}
+
+func TestHoldRegistry(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	registry := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "preview-holds"},
+		Data: map[string]string{
+			"preview-registry":      "customer demo",
+			"preview-both":          "release freeze",
+			"preview-not-expired-x": "irrelevant",
+		},
+	}
+	held := map[string]string{AnnotationHold: "true"}
+	c := newFakeClient(nil,
+		registry,
+		previewNS("preview-registry", 2*time.Hour, nil),
+		previewNS("preview-annotation", 2*time.Hour, held),
+		previewNS("preview-both", 2*time.Hour, held),
+		previewNS("preview-free", 2*time.Hour, nil),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec,
+		HoldRegistry: client.ObjectKeyFromObject(registry)}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-registry")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-annotation")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-both")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-free")).To(BeTrue())
+	close(rec.Events)
+	var events []string
+	for e := range rec.Events {
+		events = append(events, e)
+	}
+	g.Expect(events).To(ContainElements(
+		ContainSubstring("HeldByRegistry Held by hold registry platform/preview-holds: customer demo"),
+		ContainSubstring("HeldByRegistry Held by hold registry platform/preview-holds: release freeze"),
+	))
+	g.Expect(testutil.ToFloat64(lastHeld)).To(Equal(3.0))
+}
+
+func TestLastHeldResetOnListError(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	failList := false
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if failList {
+				return errors.New("apiserver unavailable")
+			}
+			return c.List(ctx, list, opts...)
+		},
+	},
+		previewNS("preview-held", 2*time.Hour, map[string]string{AnnotationHold: "true"}),
+		previewNS("preview-held-new", 10*time.Minute, map[string]string{AnnotationHold: "true"}),
+		previewNS("preview-free", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(lastHeld)).To(Equal(2.0))
+
+	failList = true
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(lastHeld)).To(BeZero())
+}
+
+func TestHoldRegistryUnreadableBlocksDeletes(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	failConfigMaps := interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); ok {
+				return errors.New("forbidden")
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	}
+	c := newFakeClient(&failConfigMaps, previewNS("preview-free", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour,
+		HoldRegistry: client.ObjectKey{Namespace: "platform", Name: "preview-holds"}}
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-free")).To(BeFalse())
+
+	// A registry that doesn't exist is simply empty
+	s.Client = newFakeClient(nil, previewNS("preview-free", 2*time.Hour, nil))
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, s.Client, "preview-free")).To(BeTrue())
+}
+
+func TestMinTTLClampsShortAnnotations(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-one-minute", 20*time.Minute, map[string]string{AnnotationTTL: "1m"}),
+		previewNS("preview-clamped-old", 2*time.Hour, map[string]string{AnnotationTTL: "1m"}),
+		previewNS("preview-long", 2*time.Hour, map[string]string{AnnotationTTL: "90m"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: 4 * time.Hour, MinTTL: time.Hour}
+	before := testutil.ToFloat64(ttlBelowMinTotal)
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-one-minute")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-clamped-old")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-long")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(ttlBelowMinTotal) - before).To(Equal(1.0))
+}
+
+func TestShadowSelectorIsObservationalOnly(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-old", 2*time.Hour, nil), // active only: deleted, shadow would not
+		previewNS("pr-old", 2*time.Hour, nil),      // shadow only: must survive
+		previewNS("pr-held", 2*time.Hour, map[string]string{AnnotationHold: "true"}),
+		previewNS("preview-pr-old", 2*time.Hour, nil), // both: same decision
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, ShadowSelector: regexp.MustCompile(`^(preview-)?pr-`)}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-pr-old")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "pr-old")).To(BeFalse(), "the shadow selector must never delete")
+	g.Expect(isDeleted(ctx, c, "pr-held")).To(BeFalse())
+	g.Expect(testutil.ToFloat64(shadowDifference.WithLabelValues("would_additionally_delete"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(shadowDifference.WithLabelValues("would_no_longer_delete"))).To(Equal(1.0))
+}
+
+func TestShadowSelectorSharesTheSweepRules(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// Young, but without pods for longer than --idle-ttl, and only the shadow selector picks it
+	idle := previewNS("preview-pr-idle", 30*time.Minute, nil)
+	idle.UID = "uid-idle"
+	c := newFakeClient(nil, idle, previewNS("preview-pr-young", 30*time.Minute, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, DryRun: true, IdleTTL: time.Minute,
+		Prefixes: []string{"pr-"}, ShadowSelector: regexp.MustCompile(`^(preview-)?pr-`),
+		lastBusy: map[types.UID]time.Time{"uid-idle": time.Now().Add(-time.Hour)}}
+	s.SweepOnce(ctx)
+
+	g.Expect(testutil.ToFloat64(shadowDifference.WithLabelValues("would_additionally_delete"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(shadowDifference.WithLabelValues("would_no_longer_delete"))).To(BeZero())
+}
+
+func TestOrderedDelete(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var deleted []string
+	recordDeletes := interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleted = append(deleted, obj.GetName())
+			return c.Delete(ctx, obj, opts...)
+		},
+	}
+	c := newFakeClient(&recordDeletes,
+		previewNS("preview-unordered", 5*time.Hour, nil),
+		previewNS("preview-db", 4*time.Hour, map[string]string{AnnotationDeleteOrder: "10"}),
+		previewNS("preview-app", 2*time.Hour, map[string]string{AnnotationDeleteOrder: "1"}),
+		previewNS("preview-garbled", 3*time.Hour, map[string]string{AnnotationDeleteOrder: "first"}),
+		previewNS("preview-worker", 3*time.Hour, map[string]string{AnnotationDeleteOrder: "1"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, OrderedDelete: true}
+	s.SweepOnce(ctx)
+
+	// Equal orders and unordered namespaces keep the oldest-first order
+	g.Expect(deleted).To(Equal([]string{"preview-worker", "preview-app", "preview-db", "preview-unordered", "preview-garbled"}))
+}
+
+func TestMaintenanceBonusDefersExpiry(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	maintenance := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "preview-maintenance"},
+		Data:       map[string]string{MaintenanceKey: "true"},
+	}
+	c := newFakeClient(nil, maintenance,
+		previewNS("preview-expired", 90*time.Minute, nil),
+		previewNS("preview-very-expired", 5*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour,
+		MaintenanceBonus: 2 * time.Hour, MaintenanceConfigMap: client.ObjectKeyFromObject(maintenance)}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-expired")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-very-expired")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(maintenanceBonus)).To(Equal((2 * time.Hour).Seconds()))
+
+	// Turning maintenance off takes effect on the next sweep
+	maintenance.Data[MaintenanceKey] = "false"
+	g.Expect(c.Update(ctx, maintenance)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-expired")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(maintenanceBonus)).To(BeZero())
+}
+
+func TestEventMessageTemplate(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	tmpl, err := ParseEventMessageTemplate(`Bye {{.Namespace.Name}} ({{index .Namespace.Annotations "pr-url"}}) after {{printf "%.0fh" .Age.Hours}}`)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = ParseEventMessageTemplate(`{{.Nope}}`)
+	g.Expect(err).To(HaveOccurred(), "unknown fields must fail at startup")
+
+	c := newFakeClient(nil, previewNS("preview-pr-42", 2*time.Hour, map[string]string{"pr-url": "https://git.example/pr/42"}))
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, EventMessageTemplate: tmpl}
+	s.SweepOnce(ctx)
+	g.Expect(rec.Events).To(Receive(Equal("Normal NamespaceCleanup Bye preview-pr-42 (https://git.example/pr/42) after 2h")))
+
+	// Render errors fall back to the default message
+	c = newFakeClient(nil, previewNS("preview-pr-43", 2*time.Hour, nil))
+	s.Client = c
+	s.EventMessageTemplate = template.Must(template.New("broken").Parse(`{{template "missing"}}`))
+	s.SweepOnce(ctx)
+	g.Expect(rec.Events).To(Receive(HavePrefix(`Normal NamespaceCleanup Deleted namespace "preview-pr-43"`)))
+
+	// .Reason tells TTL expiry from other deletions
+	c = newFakeClient(nil, previewNS("preview-pr-44", time.Minute, map[string]string{AnnotationSourceState: "merged"}))
+	s.Client = c
+	s.EventMessageTemplate = template.Must(ParseEventMessageTemplate(`{{.Namespace.Name}}: {{.Reason}}`))
+	s.SweepOnce(ctx)
+	g.Expect(rec.Events).To(Receive(ContainSubstring("SourceClosed")))
+	g.Expect(rec.Events).To(Receive(Equal(fmt.Sprintf(`Normal NamespaceCleanup preview-pr-44: %s is "merged"`, AnnotationSourceState))))
+}
+
+func TestEmptyTTLAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		never       bool
+		wantDeleted bool
+	}{
+		{never: false, wantDeleted: true},
+		{never: true, wantDeleted: false},
+	} {
+		g := NewWithT(t)
+		ctx := context.Background()
+		c := newFakeClient(nil,
+			previewNS("preview-empty", 2*time.Hour, map[string]string{AnnotationTTL: ""}),
+			previewNS("preview-unset", 2*time.Hour, nil),
+		)
+		s := &NamespaceSweeper{Client: c, TTL: time.Hour, EmptyTTLNever: tc.never}
+		s.SweepOnce(ctx)
+
+		g.Expect(isDeleted(ctx, c, "preview-empty")).To(Equal(tc.wantDeleted), "EmptyTTLNever=%v", tc.never)
+		g.Expect(isDeleted(ctx, c, "preview-unset")).To(BeTrue())
+		if tc.never {
+			g.Expect(testutil.ToFloat64(protectedByAnnotation)).To(Equal(1.0))
+		}
+	}
+}
+
+func TestInvalidCreationTimestampIsSkipped(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	zero := previewNS("preview-zero", 0, nil)
+	zero.CreationTimestamp = metav1.Time{}
+	c := newFakeClient(nil,
+		previewNS("preview-future", -2*time.Hour, nil),
+		previewNS("preview-slightly-ahead", -10*time.Second, nil),
+		zero,
+		previewNS("preview-old", 2*time.Hour, nil),
+	)
+	future := testutil.ToFloat64(invalidTimestampTotal.WithLabelValues("future-timestamp"))
+	zeroed := testutil.ToFloat64(invalidTimestampTotal.WithLabelValues("zero-timestamp"))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-future")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-zero")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-slightly-ahead")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+	g.Expect(res.Candidates).To(Equal(2))
+	g.Expect(testutil.ToFloat64(invalidTimestampTotal.WithLabelValues("future-timestamp"))).To(Equal(future + 1))
+	g.Expect(testutil.ToFloat64(invalidTimestampTotal.WithLabelValues("zero-timestamp"))).To(Equal(zeroed + 1))
+}
+
+func TestConfirmThreshold(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-survivor", 30*24*time.Hour, nil),
+		previewNS("preview-confirmed", 30*24*time.Hour, map[string]string{AnnotationConfirmDelete: "true"}),
+		previewNS("preview-expired", 2*time.Hour, nil),
+		previewNS("preview-old-delete-now", 30*24*time.Hour, map[string]string{AnnotationDeleteNow: "true"}),
+	)
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec, ConfirmThreshold: 7 * 24 * time.Hour}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-survivor")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-confirmed")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-expired")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-old-delete-now")).To(BeTrue())
+	g.Expect(res.Deleted).To(Equal(3))
+	g.Expect(testutil.ToFloat64(lastConfirmationRequired)).To(Equal(1.0))
+
+	var events []string
+	for len(rec.Events) > 0 {
+		events = append(events, <-rec.Events)
+	}
+	g.Expect(events).To(ContainElement(HavePrefix("Warning ConfirmationRequired Expired, but older than 168h0m0s")))
+}
+
+func TestDeleteSpreadStaggersDeletions(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var deletedAt []time.Time
+	recordDeletes := interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deletedAt = append(deletedAt, time.Now())
+			return c.Delete(ctx, obj, opts...)
+		},
+	}
+	c := newFakeClient(&recordDeletes,
+		previewNS("preview-a", 2*time.Hour, nil),
+		previewNS("preview-b", 3*time.Hour, nil),
+		previewNS("preview-c", 4*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, DeleteSpread: 400 * time.Millisecond}
+	start := time.Now()
+	res := s.SweepOnce(ctx)
+
+	g.Expect(res.Deleted).To(Equal(3))
+	g.Expect(deletedAt).To(HaveLen(3))
+	for i := 1; i < len(deletedAt); i++ {
+		// 200ms per gap, +-25% jitter
+		g.Expect(deletedAt[i].Sub(deletedAt[i-1])).To(BeNumerically(">=", 150*time.Millisecond), "gap %d", i)
+	}
+	// Never longer than the window, and no pause after the last deletion
+	g.Expect(time.Since(start)).To(BeNumerically("<", 600*time.Millisecond))
+
+	// The window shrinks to what is left of the interval
+	s = &NamespaceSweeper{DeleteSpread: time.Hour, Interval: time.Minute}
+	g.Expect(s.deleteSpreadWindow(time.Now().Add(-30 * time.Second))).To(BeNumerically("~", 30*time.Second, time.Second))
+	g.Expect(s.deleteSpreadWindow(time.Now().Add(-2 * time.Minute))).To(BeZero())
+}
+
+func TestWithJitter(t *testing.T) {
+	g := NewWithT(t)
+	s := &NamespaceSweeper{}
+	base := time.Minute
+
+	g.Expect(s.withJitter(base, 0)).To(Equal(base))
+	var above, below int
+	for range 1000 {
+		d := s.withJitter(base, 0.2)
+		g.Expect(d).To(BeNumerically(">=", base-6*time.Second))
+		g.Expect(d).To(BeNumerically("<=", base+6*time.Second))
+		switch {
+		case d > base:
+			above++
+		case d < base:
+			below++
+		}
+	}
+	// Uniform over ±10%, so both halves get roughly 500 draws
+	g.Expect(above).To(BeNumerically(">", 400))
+	g.Expect(below).To(BeNumerically(">", 400))
+}
+
+func TestPropagationPolicy(t *testing.T) {
+	for _, policy := range []metav1.DeletionPropagation{"", metav1.DeletePropagationForeground} {
+		t.Run(string(policy), func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			var sent *metav1.DeletionPropagation
+			c := newFakeClient(&interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					do := &client.DeleteOptions{}
+					do.ApplyOptions(opts)
+					sent = do.PropagationPolicy
+					return c.Delete(ctx, obj, opts...)
+				},
+			}, previewNS("preview-old", 2*time.Hour, nil))
+			s := &NamespaceSweeper{Client: c, TTL: time.Hour, PropagationPolicy: policy}
+			s.SweepOnce(ctx)
+
+			g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+			if policy == "" {
+				g.Expect(sent).To(BeNil())
+			} else {
+				g.Expect(sent).To(HaveValue(Equal(policy)))
+			}
+		})
+	}
+}
+
+func TestSweepOnceSerializesConcurrentCalls(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var inFlight, maxInFlight, deletes atomic.Int32
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			if n > maxInFlight.Load() {
+				maxInFlight.Store(n)
+			}
+			time.Sleep(20 * time.Millisecond)
+			return c.List(ctx, list, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deletes.Add(1)
+			return c.Delete(ctx, obj, opts...)
+		},
+	}, previewNS("preview-old", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	sweeps := testutil.ToFloat64(sweepsTotal)
+
+	results := make(chan SweepResult, 2)
+	for range 2 {
+		go func() { results <- s.SweepOnce(ctx) }()
+	}
+	deleted := (<-results).Deleted + (<-results).Deleted
+
+	g.Expect(testutil.ToFloat64(sweepsTotal)).To(Equal(sweeps + 2))
+	g.Expect(maxInFlight.Load()).To(Equal(int32(1)))
+	g.Expect(deleted).To(Equal(1))
+	g.Expect(deletes.Load()).To(Equal(int32(1)))
+}
+
+func TestOldestExpiredAge(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	failDelete := interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			return errors.New("admission webhook denied the request")
+		},
+	}
+	c := newFakeClient(&failDelete,
+		previewNS("preview-overdue", 3*time.Hour, nil),
+		previewNS("preview-expired", 90*time.Minute, nil),
+		previewNS("preview-fresh", 10*time.Minute, nil),
+		previewNS("preview-held", 10*time.Hour, map[string]string{AnnotationHold: "true"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	s.SweepOnce(ctx)
+	// preview-overdue is 2h past its TTL; held namespaces don't count
+	g.Expect(testutil.ToFloat64(oldestExpiredAge)).To(BeNumerically("~", (2 * time.Hour).Seconds(), 5))
+
+	s.TTL = 24 * time.Hour
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(oldestExpiredAge)).To(BeZero())
+}
+
+func TestParseDurationAnnotation(t *testing.T) {
+	cases := []struct {
+		raw    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"7d", 7 * 24 * time.Hour, true},
+		{"2w", 14 * 24 * time.Hour, true},
+		{" 1d ", 24 * time.Hour, true},
+		{"48", 48 * time.Hour, true},
+		{"30m", 30 * time.Minute, true},
+		{"2h45m", 2*time.Hour + 45*time.Minute, true},
+		{"7x", 0, false},
+		{"d", 0, false},
+		{"1.5d", 0, false},
+		{"7dd", 0, false},
+		{"99999999999w", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.raw, func(t *testing.T) {
+			g := NewWithT(t)
+			d, ok := parseDurationAnnotation(tc.raw)
+			g.Expect(ok).To(Equal(tc.wantOK))
+			g.Expect(d).To(Equal(tc.want))
+		})
+	}
+}
+
+func TestLastSuccessTimestamp(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	failList := false
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if failList {
+				return errors.New("apiserver unavailable")
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}, previewNS("preview-a", time.Minute, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	lastSuccessTS.Set(0)
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(lastSuccessTS)).To(BeNumerically("~", time.Now().Unix(), 2))
+
+	lastSuccessTS.Set(42)
+	failList = true
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(lastSuccessTS)).To(Equal(42.0))
+}
+
+func TestIgnoreAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-ignored", 48*time.Hour, map[string]string{AnnotationIgnore: "true", AnnotationDeleteNow: "true"}),
+		previewNS("preview-not-ignored", 48*time.Hour, map[string]string{AnnotationIgnore: "false"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(res).To(Equal(SweepResult{Scanned: 2, Candidates: 2, Expired: 1, Deleted: 1}))
+	g.Expect(isDeleted(ctx, c, "preview-ignored")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-not-ignored")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(protectedByAnnotation)).To(Equal(1.0))
+}
+
+func TestDeleteRetries(t *testing.T) {
+	gr := corev1.Resource("namespaces")
+	cases := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantDeleted  bool
+	}{
+		{"transient errors are retried", []error{
+			apierrors.NewServerTimeout(gr, "delete", 1),
+			apierrors.NewConflict(gr, "preview-retry", errors.New("the object has been modified")),
+		}, 3, true},
+		{"gives up after three attempts", []error{
+			apierrors.NewTooManyRequests("slow down", 1),
+			apierrors.NewTooManyRequests("slow down", 1),
+			apierrors.NewTooManyRequests("slow down", 1),
+		}, 3, false},
+		{"forbidden is not retried", []error{apierrors.NewForbidden(gr, "preview-retry", errors.New("denied"))}, 1, false},
+		{"not found is not retried", []error{apierrors.NewNotFound(gr, "preview-retry")}, 1, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			attempts := 0
+			c := newFakeClient(&interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					attempts++
+					if attempts <= len(tc.errs) {
+						return tc.errs[attempts-1]
+					}
+					return c.Delete(ctx, obj, opts...)
+				},
+			}, previewNS("preview-retry", 2*time.Hour, nil))
+			s := &NamespaceSweeper{Client: c, TTL: time.Hour, DeleteRetryBackoff: time.Millisecond}
+
+			res := s.SweepOnce(ctx)
+			g.Expect(attempts).To(Equal(tc.wantAttempts))
+			g.Expect(res.Deleted == 1).To(Equal(tc.wantDeleted))
+		})
+	}
+}
+
+func TestDeleteNotFoundIsAlreadyGone(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(&interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			return apierrors.NewNotFound(corev1.Resource("namespaces"), obj.GetName())
+		},
+	}, previewNS("preview-gone", 2*time.Hour, nil))
+	rec := record.NewFakeRecorder(10)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, Recorder: rec}
+	errorsBefore := testutil.ToFloat64(deletedTotal.WithLabelValues("error"))
+	goneBefore := testutil.ToFloat64(deletedTotal.WithLabelValues("already_gone"))
+
+	res := s.SweepOnce(ctx)
+	g.Expect(res.Deleted).To(BeZero())
+	g.Expect(testutil.ToFloat64(deletedTotal.WithLabelValues("error"))).To(Equal(errorsBefore))
+	g.Expect(testutil.ToFloat64(deletedTotal.WithLabelValues("already_gone"))).To(Equal(goneBefore + 1))
+	g.Expect(rec.Events).To(BeEmpty())
+}
+
+func TestShutdownDuringSweep(t *testing.T) {
+	for _, concurrency := range []int{1, 3} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			g := NewWithT(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// The first delete is under way when shutdown begins, as the client sees it
+			var mu sync.Mutex
+			attempts := 0
+			c := newFakeClient(&interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					mu.Lock()
+					attempts++
+					mu.Unlock()
+					cancel()
+					return ctx.Err()
+				},
+			},
+				previewNS("preview-a", 4*time.Hour, nil),
+				previewNS("preview-b", 3*time.Hour, nil),
+				previewNS("preview-c", 2*time.Hour, nil),
+			)
+			s := &NamespaceSweeper{Client: c, TTL: time.Hour, Concurrency: concurrency, DeleteRetryBackoff: time.Millisecond}
+			errorsBefore := testutil.ToFloat64(deletedTotal.WithLabelValues("error"))
+			var reasonsBefore float64
+			for _, reason := range []string{"forbidden", "conflict", "timeout", "other"} {
+				reasonsBefore += testutil.ToFloat64(deleteErrorsTotal.WithLabelValues(reason))
+			}
+
+			res := s.SweepOnce(ctx)
+			g.Expect(res.Deleted).To(BeZero())
+			g.Expect(testutil.ToFloat64(deletedTotal.WithLabelValues("error"))).To(Equal(errorsBefore))
+			var reasonsAfter float64
+			for _, reason := range []string{"forbidden", "conflict", "timeout", "other"} {
+				reasonsAfter += testutil.ToFloat64(deleteErrorsTotal.WithLabelValues(reason))
+			}
+			g.Expect(reasonsAfter).To(Equal(reasonsBefore))
+			if concurrency == 1 {
+				g.Expect(attempts).To(Equal(1), "no delete is started after shutdown began")
+			}
+		})
+	}
+}
+
+func TestDeleteErrorsByReason(t *testing.T) {
+	gr := corev1.Resource("namespaces")
+	cases := []struct {
+		err        error
+		wantReason string
+	}{
+		{apierrors.NewForbidden(gr, "preview-fail", errors.New("denied")), "forbidden"},
+		{apierrors.NewConflict(gr, "preview-fail", errors.New("the object has been modified")), "conflict"},
+		{apierrors.NewServerTimeout(gr, "delete", 1), "timeout"},
+		{apierrors.NewInternalError(errors.New("etcd is down")), "other"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.wantReason, func(t *testing.T) {
+			g := NewWithT(t)
+			c := newFakeClient(&interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					return tc.err
+				},
+			}, previewNS("preview-fail", 2*time.Hour, nil))
+			s := &NamespaceSweeper{Client: c, TTL: time.Hour, DeleteRetryBackoff: time.Millisecond}
+			errorsBefore := testutil.ToFloat64(deletedTotal.WithLabelValues("error"))
+			reasonBefore := testutil.ToFloat64(deleteErrorsTotal.WithLabelValues(tc.wantReason))
+
+			s.SweepOnce(context.Background())
+			g.Expect(testutil.ToFloat64(deleteErrorsTotal.WithLabelValues(tc.wantReason))).To(Equal(reasonBefore + 1))
+			// The coarse counter is kept as it was
+			g.Expect(testutil.ToFloat64(deletedTotal.WithLabelValues("error"))).To(Equal(errorsBefore + 1))
+		})
+	}
+}
+
+func TestListSkipsTerminatingNamespacesOnTheServer(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var selectors []string
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			lo := &client.ListOptions{}
+			lo.ApplyOptions(opts)
+			if lo.FieldSelector == nil {
+				selectors = append(selectors, "")
+			} else {
+				selectors = append(selectors, lo.FieldSelector.String())
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}, previewNS("preview-old", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	// The fake client, like the cache, rejects the selector, so the sweep falls back once and for all
+	g.Expect(s.SweepOnce(ctx).Deleted).To(Equal(1))
+	g.Expect(selectors).To(Equal([]string{"status.phase!=Terminating", ""}))
+	s.SweepOnce(ctx)
+	g.Expect(selectors).To(Equal([]string{"status.phase!=Terminating", "", ""}))
+}
+
+func TestTransientListErrorKeepsThePhaseSelector(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	var selectors []string
+	fail := true
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			lo := &client.ListOptions{}
+			lo.ApplyOptions(opts)
+			if lo.FieldSelector == nil {
+				selectors = append(selectors, "")
+				return c.List(ctx, list, opts...)
+			}
+			selectors = append(selectors, lo.FieldSelector.String())
+			if fail {
+				fail = false
+				return apierrors.NewServiceUnavailable("etcd leader changed")
+			}
+			// Stand in for an API server, which supports the selector
+			return c.List(ctx, list, client.MatchingLabelsSelector{Selector: lo.LabelSelector})
+		},
+	}, previewNS("preview-old", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	g.Expect(s.SweepOnce(ctx).Deleted).To(Equal(1))
+	s.SweepOnce(ctx)
+	g.Expect(selectors).To(Equal([]string{"status.phase!=Terminating", "", "status.phase!=Terminating"}))
+}
+
+func TestMinAgeFloor(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-typo", 10*time.Minute, map[string]string{AnnotationTTL: "1s"}),
+		previewNS("preview-delete-now", 10*time.Minute, map[string]string{AnnotationDeleteNow: "true"}),
+		previewNS("preview-old-enough", 2*time.Hour, map[string]string{AnnotationTTL: "1s"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: 24 * time.Hour, MinAge: time.Hour}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(res.Expired).To(Equal(3))
+	g.Expect(res.Deleted).To(Equal(1))
+	g.Expect(isDeleted(ctx, c, "preview-typo")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-delete-now")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-old-enough")).To(BeTrue())
+}
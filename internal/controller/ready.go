@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ReadyCheck is a healthz.Checker for /readyz that fails until a sweep has listed the preview
+// namespaces, so a replica lacking RBAC for them never reports ready. A replica waiting for
+// leader election (IsLeader false) doesn't sweep and is ready, and so is one whose last sweep was
+// skipped because another replica holds the SweepGuard.
+func (s *NamespaceSweeper) ReadyCheck(_ *http.Request) error {
+	if s.lastSweepSucceeded.Load() || s.guardHeldElsewhere.Load() {
+		return nil
+	}
+	if s.IsLeader != nil && !s.IsLeader() {
+		return nil
+	}
+	return errors.New("no sweep has listed the namespaces yet")
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestReadyCheck(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	forbidden := true
+	c := newFakeClient(&interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if forbidden {
+				return errors.New(`namespaces is forbidden: cannot list resource "namespaces"`)
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}, previewNS("preview-a", time.Minute, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	g.Expect(s.ReadyCheck(nil)).NotTo(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(s.ReadyCheck(nil)).NotTo(Succeed())
+
+	// A standby replica doesn't sweep
+	leader := false
+	s.IsLeader = func() bool { return leader }
+	g.Expect(s.ReadyCheck(nil)).To(Succeed())
+	leader = true
+	g.Expect(s.ReadyCheck(nil)).NotTo(Succeed())
+
+	forbidden = false
+	s.SweepOnce(ctx)
+	g.Expect(s.ReadyCheck(nil)).To(Succeed())
+
+	// Stays ready through later list errors
+	forbidden = true
+	s.SweepOnce(ctx)
+	g.Expect(s.ReadyCheck(nil)).To(Succeed())
+}
+
+func TestReadyCheckWithSweepGuard(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	guard := types.NamespacedName{Namespace: "platform", Name: "sweep-guard"}
+	c := newFakeClient(nil, previewNS("preview-a", time.Minute, nil))
+	a := &NamespaceSweeper{Client: c, TTL: time.Hour, ControllerID: "replica-a", SweepGuard: guard, SweepGuardTTL: 10 * time.Minute}
+	b := &NamespaceSweeper{Client: c, TTL: time.Hour, ControllerID: "replica-b", SweepGuard: guard, SweepGuardTTL: 10 * time.Minute}
+
+	// replica-a is mid-sweep when replica-b's sweep comes around
+	var cm corev1.ConfigMap
+	cm.Namespace, cm.Name = guard.Namespace, guard.Name
+	a.stampSweepGuard(&cm)
+	g.Expect(c.Create(ctx, &cm)).To(Succeed())
+
+	g.Expect(b.ReadyCheck(nil)).NotTo(Succeed())
+	b.SweepOnce(ctx)
+	g.Expect(b.ReadyCheck(nil)).To(Succeed(), "another replica holds the guard")
+
+	a.SweepOnce(ctx)
+	g.Expect(a.ReadyCheck(nil)).To(Succeed())
+	b.SweepOnce(ctx)
+	g.Expect(b.ReadyCheck(nil)).To(Succeed())
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AnnotationScaledToZero is stamped on a namespace (RFC 3339) once ScaleToZero scaled its
+	// workloads down; later sweeps leave its workloads alone.
+	AnnotationScaledToZero = "preview-sweeper.maxsauce.com/scaled-to-zero"
+	// AnnotationReplicasBeforeScale records a workload's replica count before it was scaled to
+	// zero, so it can be scaled back up by hand.
+	AnnotationReplicasBeforeScale = "preview-sweeper.maxsauce.com/replicas-before-scale"
+)
+
+var workloadsScaledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "workloads_scaled_to_zero_total",
+	Help: "Total Deployments and StatefulSets scaled to zero replicas in expired namespaces, by kind.",
+}, []string{"kind"})
+
+func init() {
+	registerMetrics(workloadsScaledTotal)
+}
+
+// scaleToZero scales every Deployment and StatefulSet in ns with replicas to zero, then stamps
+// AnnotationScaledToZero on ns. It returns how many workloads it scaled. On error the namespace is
+// not stamped, so the next sweep retries.
+func (s *NamespaceSweeper) scaleToZero(ctx context.Context, ns *corev1.Namespace, now time.Time) (int, error) {
+	scaled := 0
+
+	var deployments appsv1.DeploymentList
+	if err := s.Client.List(ctx, &deployments, client.InNamespace(ns.Name)); err != nil {
+		return scaled, err
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		ok, err := s.scaleWorkload(ctx, d, &d.Spec.Replicas)
+		if err != nil {
+			return scaled, err
+		}
+		if ok {
+			workloadsScaledTotal.WithLabelValues("Deployment").Inc()
+			scaled++
+		}
+	}
+
+	var statefulSets appsv1.StatefulSetList
+	if err := s.Client.List(ctx, &statefulSets, client.InNamespace(ns.Name)); err != nil {
+		return scaled, err
+	}
+	for i := range statefulSets.Items {
+		st := &statefulSets.Items[i]
+		ok, err := s.scaleWorkload(ctx, st, &st.Spec.Replicas)
+		if err != nil {
+			return scaled, err
+		}
+		if ok {
+			workloadsScaledTotal.WithLabelValues("StatefulSet").Inc()
+			scaled++
+		}
+	}
+
+	patch := client.MergeFrom(ns.DeepCopy())
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[AnnotationScaledToZero] = now.UTC().Format(time.RFC3339)
+	return scaled, s.Client.Patch(ctx, ns, patch, s.fieldOwner())
+}
+
+// scaleWorkload patches *replicas of obj to zero, recording the previous count in
+// AnnotationReplicasBeforeScale. Workloads already at zero are left alone. A nil replicas
+// defaults to 1 on the apiserver.
+func (s *NamespaceSweeper) scaleWorkload(ctx context.Context, obj client.Object, replicas **int32) (bool, error) {
+	before := int32(1)
+	if *replicas != nil {
+		before = **replicas
+	}
+	if before == 0 {
+		return false, nil
+	}
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AnnotationReplicasBeforeScale] = strconv.Itoa(int(before))
+	obj.SetAnnotations(annotations)
+	zero := int32(0)
+	*replicas = &zero
+	return true, s.Client.Patch(ctx, obj, patch, s.fieldOwner())
+}
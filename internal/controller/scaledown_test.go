@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func replicas(n int32) *int32 { return &n }
+
+func TestScaleToZeroInGracePeriod(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	web := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "preview-soft", Name: "web"},
+		Spec: appsv1.DeploymentSpec{Replicas: replicas(3)}}
+	idle := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "preview-soft", Name: "idle"},
+		Spec: appsv1.DeploymentSpec{Replicas: replicas(0)}}
+	db := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "preview-soft", Name: "db"}}
+	fresh := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "preview-fresh", Name: "web"},
+		Spec: appsv1.DeploymentSpec{Replicas: replicas(2)}}
+	c := newFakeClient(nil,
+		previewNS("preview-soft", 90*time.Minute, nil),
+		previewNS("preview-fresh", 30*time.Minute, nil),
+		previewNS("preview-hard", 3*time.Hour, nil),
+		web, idle, db, fresh,
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, GracePeriod: time.Hour, ScaleToZero: true}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-soft")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-hard")).To(BeTrue())
+
+	var d appsv1.Deployment
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(web), &d)).To(Succeed())
+	g.Expect(d.Spec.Replicas).To(HaveValue(BeEquivalentTo(0)))
+	g.Expect(d.Annotations).To(HaveKeyWithValue(AnnotationReplicasBeforeScale, "3"))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(idle), &d)).To(Succeed())
+	g.Expect(d.Annotations).NotTo(HaveKey(AnnotationReplicasBeforeScale))
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(fresh), &d)).To(Succeed())
+	g.Expect(d.Spec.Replicas).To(HaveValue(BeEquivalentTo(2)))
+
+	var st appsv1.StatefulSet
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(db), &st)).To(Succeed())
+	g.Expect(st.Spec.Replicas).To(HaveValue(BeEquivalentTo(0)))
+	g.Expect(st.Annotations).To(HaveKeyWithValue(AnnotationReplicasBeforeScale, "1"))
+
+	var ns corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-soft"}, &ns)).To(Succeed())
+	g.Expect(ns.Annotations).To(HaveKey(AnnotationScaledToZero))
+
+	// Once stamped, workloads scaled back up by hand are left alone
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(web), &d)).To(Succeed())
+	d.Spec.Replicas = replicas(1)
+	g.Expect(c.Update(ctx, &d)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(web), &d)).To(Succeed())
+	g.Expect(d.Spec.Replicas).To(HaveValue(BeEquivalentTo(1)))
+}
+
+func TestScaleToZeroSkippedInDryRun(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	web := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "preview-soft", Name: "web"},
+		Spec: appsv1.DeploymentSpec{Replicas: replicas(3)}}
+	c := newFakeClient(nil, previewNS("preview-soft", 90*time.Minute, nil), web)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, GracePeriod: time.Hour, ScaleToZero: true, DryRun: true}
+	s.SweepOnce(ctx)
+
+	var d appsv1.Deployment
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(web), &d)).To(Succeed())
+	g.Expect(d.Spec.Replicas).To(HaveValue(BeEquivalentTo(3)))
+	var ns corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-soft"}, &ns)).To(Succeed())
+	g.Expect(ns.Annotations).NotTo(HaveKey(AnnotationScaledToZero))
+}
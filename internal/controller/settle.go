@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// settleState is when a namespace's metadata last changed, as far as the sweeper can tell.
+type settleState struct {
+	resourceVersion string
+	since           time.Time
+}
+
+// trackChange returns ns's settleState as of now. A resourceVersion different from the previous
+// sweep's restarts the clock. A namespace seen for the first time, e.g. after a restart, dates
+// from its latest managedFields update by someone other than the sweeper, or now if there is none.
+func (s *NamespaceSweeper) trackChange(ns *corev1.Namespace, now time.Time) settleState {
+	prev, seen := s.lastChanges[ns.UID]
+	switch {
+	case seen && prev.resourceVersion == ns.ResourceVersion:
+		return prev
+	case !seen:
+		if t, ok := s.lastUpdate(ns); ok {
+			return settleState{resourceVersion: ns.ResourceVersion, since: minTime(t, now)}
+		}
+	}
+	return settleState{resourceVersion: ns.ResourceVersion, since: now}
+}
+
+// lastUpdate is the latest managedFields timestamp not written by the sweeper itself.
+func (s *NamespaceSweeper) lastUpdate(ns *corev1.Namespace) (time.Time, bool) {
+	self := string(s.fieldOwner())
+	var latest time.Time
+	for _, mf := range ns.ManagedFields {
+		if mf.Manager != self && mf.Time != nil && mf.Time.After(latest) {
+			latest = mf.Time.Time
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSettlePeriodDebouncesChanges(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	flapping := previewNS("preview-flapping", 2*time.Hour, nil)
+	flapping.UID = "flapping"
+	quiet := previewNS("preview-quiet", 2*time.Hour, nil)
+	quiet.UID = "quiet"
+	touched := metav1.NewTime(time.Now().Add(-time.Hour))
+	quiet.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, Time: &touched}}
+	c := newFakeClient(nil, flapping, quiet)
+
+	const settle = 200 * time.Millisecond
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, SettlePeriod: settle, TrackSweepCount: true}
+
+	// Unchanged for an hour according to managedFields, so it goes right away
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-quiet")).To(BeTrue())
+
+	// CI flips the hold annotation off and on between sweeps: never deleted while it changes
+	for i := range 4 {
+		var ns corev1.Namespace
+		g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-flapping"}, &ns)).To(Succeed())
+		ns.Annotations[AnnotationHold] = []string{"true", "false"}[i%2]
+		g.Expect(c.Update(ctx, &ns)).To(Succeed())
+		time.Sleep(settle)
+		s.SweepOnce(ctx)
+		g.Expect(isDeleted(ctx, c, "preview-flapping")).To(BeFalse(), "sweep %d", i)
+	}
+
+	// Once it settles it goes; the sweeper's own sweep-count patches don't reset the clock
+	var ns corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-flapping"}, &ns)).To(Succeed())
+	delete(ns.Annotations, AnnotationHold)
+	g.Expect(c.Update(ctx, &ns)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-flapping")).To(BeFalse())
+	time.Sleep(settle)
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-flapping")).To(BeTrue())
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var shadowDifference = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "shadow_selector_difference",
+	Help: "Namespaces the --shadow-selector would decide differently in the last sweep.",
+}, []string{"difference"}) // difference=would_additionally_delete|would_no_longer_delete
+
+func init() {
+	registerMetrics(shadowDifference)
+}
+
+// shadowDecisions evaluates the sweep with names selected by ShadowSelector instead of the
+// Prefixes, and returns the namespaces it would delete. It runs the sweep's own rules, so only
+// the selection differs from the active decisions. It is called from within a sweep, holding
+// sweepMu, and logs nothing so the evaluation doesn't repeat the sweep's log lines.
+func (s *NamespaceSweeper) shadowDecisions(ctx context.Context) (map[string]bool, bool) {
+	s.shadowing = true
+	defer func() { s.shadowing = false }()
+	report, ok := s.evaluateLocked(log.IntoContext(ctx, logr.Discard()), s.Client)
+	if !ok {
+		return nil, false
+	}
+	shadow := map[string]bool{}
+	for _, e := range report.Namespaces {
+		if e.Disposition == DispositionWouldDelete {
+			shadow[e.Namespace] = true
+		}
+	}
+	return shadow, true
+}
+
+// compareShadow logs and exports how the shadow selector's decisions differ from active, the
+// names the active rules decided to delete.
+func (s *NamespaceSweeper) compareShadow(logger logr.Logger, active, shadow map[string]bool) {
+	var additionally, noLonger []string
+	for name := range shadow {
+		if !active[name] {
+			additionally = append(additionally, name)
+		}
+	}
+	for name := range active {
+		if !shadow[name] {
+			noLonger = append(noLonger, name)
+		}
+	}
+	sort.Strings(additionally)
+	sort.Strings(noLonger)
+
+	shadowDifference.WithLabelValues("would_additionally_delete").Set(float64(len(additionally)))
+	shadowDifference.WithLabelValues("would_no_longer_delete").Set(float64(len(noLonger)))
+	if len(additionally) > 0 || len(noLonger) > 0 {
+		logger.Info("Shadow selector disagrees with the active selection",
+			"shadowSelector", s.ShadowSelector.String(),
+			"wouldAdditionallyDelete", additionally,
+			"wouldNoLongerDelete", noLonger)
+	}
+}
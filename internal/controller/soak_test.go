@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// soakNamespaces returns n preview namespaces; every other one is expired under a 1h TTL.
+func soakNamespaces(n int) []client.Object {
+	objs := make([]client.Object, 0, n)
+	for i := range n {
+		age := 30 * time.Minute
+		if i%2 == 0 {
+			age = 2 * time.Hour
+		}
+		ns := previewNS(fmt.Sprintf("preview-soak-%05d", i), age, nil)
+		ns.UID = types.UID(fmt.Sprintf("uid-%05d", i))
+		objs = append(objs, ns)
+	}
+	return objs
+}
+
+// sweeperSeries counts the preview_sweeper_* series in the controller-runtime registry.
+func sweeperSeries(t *testing.T) int {
+	t.Helper()
+	families, err := crmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, mf := range families {
+		if strings.HasPrefix(mf.GetName(), "preview_sweeper_") {
+			n += len(mf.GetMetric())
+		}
+	}
+	return n
+}
+
+func heapAlloc() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// TestSoakSweepLoop runs many sweeps over a few thousand namespaces and checks that goroutines,
+// memory, per-sweep state and metric series stay bounded.
+func TestSoakSweepLoop(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak test skipped in -short mode")
+	}
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	const namespaces, passes = 2000, 30
+	c := newFakeClient(nil, soakNamespaces(namespaces)...)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+
+	first := s.SweepOnce(ctx)
+	g.Expect(first.Scanned).To(Equal(namespaces))
+	g.Expect(first.Deleted).To(Equal(namespaces / 2))
+
+	goroutines := runtime.NumGoroutine()
+	series := sweeperSeries(t)
+	heap := heapAlloc()
+
+	for range passes {
+		res := s.SweepOnce(ctx)
+		g.Expect(res.Scanned).To(Equal(namespaces / 2))
+		g.Expect(res.Deleted).To(BeZero())
+	}
+
+	g.Expect(runtime.NumGoroutine()).To(BeNumerically("<=", goroutines+2))
+	g.Expect(sweeperSeries(t)).To(Equal(series))
+	g.Expect(len(s.lastTTLs)).To(Equal(namespaces / 2))
+	// Allow for allocator noise, not for growth proportional to passes.
+	g.Expect(heapAlloc()).To(BeNumerically("<", heap+16<<20))
+}
+
+func BenchmarkSweepOnce(b *testing.B) {
+	ctx := context.Background()
+	objs := soakNamespaces(2000)
+	for _, o := range objs {
+		// Nothing expires, so every iteration sweeps the same set.
+		o.SetCreationTimestamp(objs[1].GetCreationTimestamp())
+	}
+	s := &NamespaceSweeper{Client: newFakeClient(nil, objs...), TTL: time.Hour}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		s.SweepOnce(ctx)
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// neverTouchedSlack is how long after creation a write still counts as part of creating the
+// namespace, e.g. a CI job labelling it right after `kubectl create`.
+const neverTouchedSlack = time.Minute
+
+// staleTTL shortens ttl by StaleFactor for a namespace that nobody has written to since it was
+// created, judged from its managedFields. The sweeper's own writes don't count. The result never
+// drops below MinTTL.
+func (s *NamespaceSweeper) staleTTL(ns *corev1.Namespace, ttl time.Duration) (time.Duration, bool) {
+	if s.StaleFactor <= 0 || s.StaleFactor >= 1 || ttl <= 0 || !s.neverTouched(ns) {
+		return ttl, false
+	}
+	return max(time.Duration(float64(ttl)*s.StaleFactor), s.MinTTL), true
+}
+
+// neverTouched reports whether every managedFields entry, apart from the sweeper's own, dates
+// from within neverTouchedSlack of creation. Without managedFields nothing can be told, so the
+// namespace counts as touched.
+func (s *NamespaceSweeper) neverTouched(ns *corev1.Namespace) bool {
+	if len(ns.ManagedFields) == 0 {
+		return false
+	}
+	self := string(s.fieldOwner())
+	created := ns.CreationTimestamp.Time
+	for _, mf := range ns.ManagedFields {
+		if mf.Manager == self || mf.Time == nil {
+			continue
+		}
+		if mf.Time.Sub(created) > neverTouchedSlack {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStaleTTL(t *testing.T) {
+	entry := func(manager string, after time.Duration, created time.Time) metav1.ManagedFieldsEntry {
+		ts := metav1.NewTime(created.Add(after))
+		return metav1.ManagedFieldsEntry{Manager: manager, Operation: metav1.ManagedFieldsOperationUpdate, Time: &ts}
+	}
+	cases := []struct {
+		name    string
+		fields  func(created time.Time) []metav1.ManagedFieldsEntry
+		minTTL  time.Duration
+		wantTTL time.Duration
+	}{
+		{"no managedFields", func(time.Time) []metav1.ManagedFieldsEntry { return nil }, 0, 4 * time.Hour},
+		{"only created", func(c time.Time) []metav1.ManagedFieldsEntry {
+			return []metav1.ManagedFieldsEntry{entry("kubectl-create", 0, c), entry("ci", 20*time.Second, c)}
+		}, 0, time.Hour},
+		{"updated later", func(c time.Time) []metav1.ManagedFieldsEntry {
+			return []metav1.ManagedFieldsEntry{entry("kubectl-create", 0, c), entry("kubectl-annotate", time.Hour, c)}
+		}, 0, 4 * time.Hour},
+		{"the sweeper's own writes don't count", func(c time.Time) []metav1.ManagedFieldsEntry {
+			return []metav1.ManagedFieldsEntry{entry("kubectl-create", 0, c), entry(DefaultFieldManager, time.Hour, c)}
+		}, 0, time.Hour},
+		{"never below min-ttl", func(c time.Time) []metav1.ManagedFieldsEntry {
+			return []metav1.ManagedFieldsEntry{entry("kubectl-create", 0, c)}
+		}, 2 * time.Hour, 2 * time.Hour},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ns := previewNS("preview-a", 3*time.Hour, nil)
+			ns.ManagedFields = tc.fields(ns.CreationTimestamp.Time)
+			s := &NamespaceSweeper{StaleFactor: 0.25, MinTTL: tc.minTTL}
+			ttl, _ := s.staleTTL(ns, 4*time.Hour)
+			g.Expect(ttl).To(Equal(tc.wantTTL))
+		})
+	}
+
+	// Disabled, or a factor that wouldn't shorten anything
+	g := NewWithT(t)
+	ns := previewNS("preview-a", 3*time.Hour, nil)
+	ns.ManagedFields = []metav1.ManagedFieldsEntry{entry("kubectl-create", 0, ns.CreationTimestamp.Time)}
+	for _, f := range []float64{0, 1} {
+		_, ok := (&NamespaceSweeper{StaleFactor: f}).staleTTL(ns, 4*time.Hour)
+		g.Expect(ok).To(BeFalse())
+	}
+}
+
+func TestStaleFactorDeletesNeverTouchedNamespaces(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	untouched := previewNS("preview-untouched", 40*time.Minute, nil)
+	touched := previewNS("preview-touched", 40*time.Minute, nil)
+	for _, ns := range []*metav1.ObjectMeta{&untouched.ObjectMeta, &touched.ObjectMeta} {
+		created := metav1.NewTime(ns.CreationTimestamp.Time)
+		ns.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl-create", Operation: metav1.ManagedFieldsOperationUpdate, Time: &created}}
+	}
+	later := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	touched.ManagedFields = append(touched.ManagedFields,
+		metav1.ManagedFieldsEntry{Manager: "kubectl-label", Operation: metav1.ManagedFieldsOperationUpdate, Time: &later})
+
+	c := newFakeClient(nil, untouched, touched)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, StaleFactor: 0.5}
+	s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-untouched")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-touched")).To(BeFalse())
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LabelDeletedBy is stamped with StampOnDelete on namespaces right before the sweeper deletes
+	// them, so tooling watching namespaces can attribute their termination.
+	LabelDeletedBy = "preview-sweeper.maxsauce.com/deleted-by"
+	// AnnotationDeletedAt is stamped along with LabelDeletedBy: when the deletion was issued (RFC 3339).
+	AnnotationDeletedAt = "preview-sweeper.maxsauce.com/deleted-at"
+
+	deletedByValue = "preview-sweeper"
+)
+
+var stampErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "stamp_errors_total",
+	Help: "Total namespaces deleted without the deleted-by stamp because patching it failed.",
+})
+
+func init() {
+	registerMetrics(stampErrorsTotal)
+}
+
+// stampDeletion patches LabelDeletedBy and AnnotationDeletedAt onto ns. With APIReader the patch
+// carries ns's resourceVersion, so it fails rather than hide a change made since the namespace
+// was re-read, and ns's new resourceVersion is the one the delete preconditions must carry.
+func (s *NamespaceSweeper) stampDeletion(ctx context.Context, ns *corev1.Namespace, now time.Time) error {
+	var opts []client.MergeFromOption
+	if s.APIReader != nil {
+		opts = append(opts, client.MergeFromWithOptimisticLock{})
+	}
+	patch := client.MergeFromWithOptions(ns.DeepCopy(), opts...)
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Labels[LabelDeletedBy] = deletedByValue
+	ns.Annotations[AnnotationDeletedAt] = now.UTC().Format(time.RFC3339)
+	return s.Client.Patch(ctx, ns, patch, s.fieldOwner())
+}
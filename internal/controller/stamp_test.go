@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestStampOnDelete(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// The finalizer keeps the namespace around after the delete, so the stamp can be inspected
+	ns := previewNS("preview-stamped", 2*time.Hour, nil)
+	ns.Finalizers = []string{"example.com/keep"}
+	c := newFakeClient(nil, ns)
+	s := &NamespaceSweeper{Client: c, APIReader: c, TTL: time.Hour, StampOnDelete: true}
+
+	g.Expect(s.SweepOnce(ctx).Deleted).To(Equal(1))
+	var got corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-stamped"}, &got)).To(Succeed())
+	g.Expect(got.DeletionTimestamp).NotTo(BeNil())
+	g.Expect(got.Labels).To(HaveKeyWithValue(LabelDeletedBy, "preview-sweeper"))
+	deletedAt, err := time.Parse(time.RFC3339, got.Annotations[AnnotationDeletedAt])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(deletedAt).To(BeTemporally("~", time.Now(), 5*time.Second))
+}
+
+func TestStampFailureStillDeletes(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(&interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			return errors.New("admission webhook denied the request")
+		},
+	}, previewNS("preview-unstamped", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, StampOnDelete: true}
+	failures := testutil.ToFloat64(stampErrorsTotal)
+
+	g.Expect(s.SweepOnce(ctx).Deleted).To(Equal(1))
+	g.Expect(isDeleted(ctx, c, "preview-unstamped")).To(BeTrue())
+	g.Expect(testutil.ToFloat64(stampErrorsTotal)).To(Equal(failures + 1))
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+)
+
+type summaryKey struct {
+	ttlSource string
+	result    string
+}
+
+// countDeletion records a deletion outcome (deleted|dry_run|error) in the metrics and lifetime summary.
+func (s *NamespaceSweeper) countDeletion(result, ttlSource string) {
+	deletedTotal.WithLabelValues(result).Inc()
+
+	s.summaryMu.Lock()
+	defer s.summaryMu.Unlock()
+	if s.summary == nil {
+		s.summary = map[summaryKey]int{}
+	}
+	s.summary[summaryKey{ttlSource: ttlSource, result: result}]++
+}
+
+// writeSummary prints the lifetime deletion outcomes as a table, by ttlSource and result.
+func (s *NamespaceSweeper) writeSummary(w io.Writer) {
+	s.summaryMu.Lock()
+	defer s.summaryMu.Unlock()
+
+	keys := make([]summaryKey, 0, len(s.summary))
+	for k := range s.summary {
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		_, _ = fmt.Fprintln(w, "preview-sweeper: no namespace deletions during this run")
+		return
+	}
+	slices.SortFunc(keys, func(a, b summaryKey) int {
+		return cmp.Or(cmp.Compare(a.ttlSource, b.ttlSource), cmp.Compare(a.result, b.result))
+	})
+
+	_, _ = fmt.Fprintln(w, "preview-sweeper: namespace deletions during this run")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TTL SOURCE\tRESULT\tCOUNT")
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\n", k.ttlSource, k.result, s.summary[k])
+	}
+	_ = tw.Flush()
+}
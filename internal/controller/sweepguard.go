@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AnnotationSweepingSince is stamped on the SweepGuard ConfigMap (RFC 3339) while a replica sweeps.
+	AnnotationSweepingSince = "preview-sweeper.maxsauce.com/sweeping-since"
+	// AnnotationSweepingBy names the replica (ControllerID) holding the sweep guard.
+	AnnotationSweepingBy = "preview-sweeper.maxsauce.com/sweeping-by"
+)
+
+var sweepGuardSkipsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "sweep_guard_skips_total",
+	Help: "Total sweeps skipped because another replica held the sweep guard or the guard could not be taken.",
+})
+
+func init() {
+	registerMetrics(sweepGuardSkipsTotal)
+}
+
+// acquireSweepGuard stamps AnnotationSweepingSince on the SweepGuard ConfigMap, creating it if
+// needed, unless another replica's stamp is younger than SweepGuardTTL. Writes carry the
+// resourceVersion they read, so of two replicas racing for the guard only one wins. It reports
+// whether this replica may sweep; if so, release must be called when the sweep is done.
+func (s *NamespaceSweeper) acquireSweepGuard(ctx context.Context, logger logr.Logger) (release func(), ok bool) {
+	guard := s.SweepGuard.String()
+	var cm corev1.ConfigMap
+	err := s.Client.Get(ctx, s.SweepGuard, &cm)
+	switch {
+	case apierrors.IsNotFound(err):
+		cm = corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: s.SweepGuard.Namespace, Name: s.SweepGuard.Name}}
+		s.stampSweepGuard(&cm)
+		err = s.Client.Create(ctx, &cm, s.fieldOwner())
+	case err != nil:
+	default:
+		if by, fresh := s.sweepGuardHolder(&cm); fresh {
+			sweepGuardSkipsTotal.Inc()
+			s.guardHeldElsewhere.Store(true)
+			logger.Info("Skipping sweep, another replica is sweeping", "guard", guard, "holder", by,
+				"since", cm.Annotations[AnnotationSweepingSince])
+			return nil, false
+		}
+		s.stampSweepGuard(&cm)
+		err = s.Client.Update(ctx, &cm, s.fieldOwner())
+	}
+	if err != nil {
+		sweepGuardSkipsTotal.Inc()
+		if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+			s.guardHeldElsewhere.Store(true)
+			logger.Info("Skipping sweep, another replica took the sweep guard first", "guard", guard)
+		} else {
+			logger.Error(err, "Failed to take the sweep guard, skipping sweep", "guard", guard)
+		}
+		return nil, false
+	}
+	s.guardHeldElsewhere.Store(false)
+
+	return func() {
+		// Release even when the sweep was cancelled by shutdown, or the stamp blocks others for SweepGuardTTL
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		delete(cm.Annotations, AnnotationSweepingSince)
+		delete(cm.Annotations, AnnotationSweepingBy)
+		if err := s.Client.Update(ctx, &cm, s.fieldOwner()); err != nil {
+			logger.Error(err, "Failed to release the sweep guard, it expires on its own", "guard", guard,
+				"ttl", s.SweepGuardTTL.String())
+		}
+	}, true
+}
+
+// sweepGuardHolder returns the replica holding the guard, and whether its stamp is still fresh.
+// A replica's own stamp, left behind by a crash, never blocks it.
+func (s *NamespaceSweeper) sweepGuardHolder(cm *corev1.ConfigMap) (string, bool) {
+	by := cm.Annotations[AnnotationSweepingBy]
+	since, err := time.Parse(time.RFC3339, cm.Annotations[AnnotationSweepingSince])
+	if err != nil || (by != "" && by == s.ControllerID) {
+		return by, false
+	}
+	return by, time.Since(since) < s.SweepGuardTTL
+}
+
+func (s *NamespaceSweeper) stampSweepGuard(cm *corev1.ConfigMap) {
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[AnnotationSweepingSince] = time.Now().UTC().Format(time.RFC3339)
+	cm.Annotations[AnnotationSweepingBy] = s.ControllerID
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func guardConfigMap(by string, since time.Time) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "platform",
+		Name:      "sweep-guard",
+		Annotations: map[string]string{
+			AnnotationSweepingBy:    by,
+			AnnotationSweepingSince: since.UTC().Format(time.RFC3339),
+		},
+	}}
+}
+
+func TestSweepGuard(t *testing.T) {
+	guard := types.NamespacedName{Namespace: "platform", Name: "sweep-guard"}
+	cases := []struct {
+		name        string
+		existing    *corev1.ConfigMap
+		wantDeleted bool
+	}{
+		{"fresh stamp of another replica skips", guardConfigMap("replica-b", time.Now().Add(-time.Minute)), false},
+		{"stale stamp is taken over", guardConfigMap("replica-b", time.Now().Add(-time.Hour)), true},
+		{"own stamp left by a crash is taken over", guardConfigMap("replica-a", time.Now().Add(-time.Minute)), true},
+		{"released guard is taken", &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "platform", Name: "sweep-guard"}}, true},
+		{"missing guard is created", nil, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			objs := []client.Object{previewNS("preview-old", 2*time.Hour, nil)}
+			if tc.existing != nil {
+				objs = append(objs, tc.existing)
+			}
+			c := newFakeClient(nil, objs...)
+			s := &NamespaceSweeper{Client: c, TTL: time.Hour, ControllerID: "replica-a", SweepGuard: guard, SweepGuardTTL: 10 * time.Minute}
+			skipsBefore := testutil.ToFloat64(sweepGuardSkipsTotal)
+			s.SweepOnce(ctx)
+
+			g.Expect(isDeleted(ctx, c, "preview-old")).To(Equal(tc.wantDeleted))
+			var cm corev1.ConfigMap
+			g.Expect(c.Get(ctx, guard, &cm)).To(Succeed())
+			if tc.wantDeleted {
+				g.Expect(cm.Annotations).NotTo(HaveKey(AnnotationSweepingSince), "the guard is released after the sweep")
+				g.Expect(testutil.ToFloat64(sweepGuardSkipsTotal)).To(Equal(skipsBefore))
+			} else {
+				g.Expect(cm.Annotations).To(HaveKeyWithValue(AnnotationSweepingBy, "replica-b"))
+				g.Expect(testutil.ToFloat64(sweepGuardSkipsTotal)).To(Equal(skipsBefore + 1))
+			}
+		})
+	}
+}
+
+func TestSweepGuardLosingARaceSkips(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// Another replica updates the guard between our Get and Update
+	c := newFakeClient(&interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			return apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, obj.GetName(), nil)
+		},
+	}, previewNS("preview-old", 2*time.Hour, nil), guardConfigMap("replica-b", time.Now().Add(-time.Hour)))
+	s := &NamespaceSweeper{
+		Client: c, TTL: time.Hour, ControllerID: "replica-a",
+		SweepGuard: types.NamespacedName{Namespace: "platform", Name: "sweep-guard"}, SweepGuardTTL: 10 * time.Minute,
+	}
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeFalse())
+}
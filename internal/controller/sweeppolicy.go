@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	sweeperv1 "github.com/seekin4u/preview-sweeper/api/v1"
+)
+
+// sweepPolicy is a valid SweepPolicy, ready to match namespaces.
+type sweepPolicy struct {
+	name             string
+	selector         labels.Selector
+	prefix           string
+	ttl              time.Duration
+	holdAnnotation   string
+	ignoreAnnotation string
+	dryRun           bool
+}
+
+// compileSweepPolicy validates p. It must select namespaces by label, name prefix or both, so a
+// policy can't match every namespace in the cluster.
+func compileSweepPolicy(p *sweeperv1.SweepPolicy) (sweepPolicy, error) {
+	out := sweepPolicy{
+		name:             p.Name,
+		selector:         labels.Everything(),
+		prefix:           p.Spec.NamePrefix,
+		holdAnnotation:   p.Spec.HoldAnnotation,
+		ignoreAnnotation: p.Spec.IgnoreAnnotation,
+		dryRun:           p.Spec.DryRun,
+	}
+	var errs []error
+	if p.Spec.Selector != nil {
+		sel, err := metav1.LabelSelectorAsSelector(p.Spec.Selector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("selector: %w", err))
+		} else {
+			out.selector = sel
+		}
+	}
+	if out.prefix == "" && (p.Spec.Selector == nil || out.selector.Empty()) {
+		errs = append(errs, errors.New("selector or namePrefix must be set"))
+	}
+	if ttl, ok := parseDurationAnnotation(p.Spec.TTL); !ok || ttl <= 0 {
+		errs = append(errs, fmt.Errorf("ttl %q: must be a positive duration", p.Spec.TTL))
+	} else {
+		out.ttl = ttl
+	}
+	for field, key := range map[string]string{"holdAnnotation": out.holdAnnotation, "ignoreAnnotation": out.ignoreAnnotation} {
+		if key == "" {
+			continue
+		}
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("%s %q: %s", field, key, strings.Join(msgs, "; ")))
+		}
+	}
+	slices.SortFunc(errs, func(a, b error) int { return cmp.Compare(a.Error(), b.Error()) })
+	return out, errors.Join(errs...)
+}
+
+// loadSweepPolicies returns the valid SweepPolicies, most specific first: longest name prefix,
+// then name. It returns none when SweepPolicies is off or the CRD is not installed (any more), so
+// the flag configuration applies.
+func (s *NamespaceSweeper) loadSweepPolicies(ctx context.Context, logger logr.Logger) ([]sweepPolicy, error) {
+	if !s.SweepPolicies {
+		return nil, nil
+	}
+	var list sweeperv1.SweepPolicyList
+	if err := s.Client.List(ctx, &list); err != nil {
+		if meta.IsNoMatchError(err) {
+			logger.V(1).Info("SweepPolicy CRD not installed, using the flag configuration")
+			return nil, nil
+		}
+		return nil, err
+	}
+	var policies []sweepPolicy
+	for i := range list.Items {
+		p, err := compileSweepPolicy(&list.Items[i])
+		if err != nil {
+			logger.V(1).Info("Ignoring invalid SweepPolicy", "policy", p.name, "error", err.Error())
+			continue
+		}
+		policies = append(policies, p)
+	}
+	slices.SortFunc(policies, func(a, b sweepPolicy) int {
+		if c := cmp.Compare(len(b.prefix), len(a.prefix)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+	return policies, nil
+}
+
+// matchSweepPolicy returns the first of policies that selects ns.
+func matchSweepPolicy(policies []sweepPolicy, ns *corev1.Namespace) (*sweepPolicy, bool) {
+	for i := range policies {
+		p := &policies[i]
+		if strings.HasPrefix(ns.Name, p.prefix) && p.selector.Matches(labels.Set(ns.Labels)) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// ignoredBy returns the annotation that opts ns out of sweeping, AnnotationIgnore or the ignore
+// annotation key of its policy, if any is "true".
+func ignoredBy(ns *corev1.Namespace, policy *sweepPolicy) (string, bool) {
+	if ns.Annotations[AnnotationIgnore] == "true" {
+		return AnnotationIgnore, true
+	}
+	if policy != nil && policy.ignoreAnnotation != "" && ns.Annotations[policy.ignoreAnnotation] == "true" {
+		return policy.ignoreAnnotation, true
+	}
+	return "", false
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sweeperv1 "github.com/seekin4u/preview-sweeper/api/v1"
+)
+
+// ConditionValid is the SweepPolicy condition saying whether the sweeper uses the policy.
+const ConditionValid = "Valid"
+
+// SweepPolicyReconciler reports in each SweepPolicy's Valid condition whether the policy is
+// usable. The sweeper reads policies on its own every sweep, skipping invalid ones, so this only
+// gives their authors feedback.
+type SweepPolicyReconciler struct {
+	client.Client
+
+	// FieldManager attributes status writes to this field manager; empty means DefaultFieldManager.
+	FieldManager string
+}
+
+// +kubebuilder:rbac:groups=preview-sweeper.maxsauce.com,resources=sweeppolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=preview-sweeper.maxsauce.com,resources=sweeppolicies/status,verbs=get;update;patch
+
+func (r *SweepPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var p sweeperv1.SweepPolicy
+	if err := r.Get(ctx, req.NamespacedName, &p); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	cond := metav1.Condition{
+		Type:               ConditionValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "The policy is in use",
+		ObservedGeneration: p.Generation,
+	}
+	if _, err := compileSweepPolicy(&p); err != nil {
+		cond.Status, cond.Reason, cond.Message = metav1.ConditionFalse, "Invalid", err.Error()
+	}
+	changed := meta.SetStatusCondition(&p.Status.Conditions, cond)
+	if !changed && p.Status.ObservedGeneration == p.Generation {
+		return ctrl.Result{}, nil
+	}
+	p.Status.ObservedGeneration = p.Generation
+	owner := client.FieldOwner(DefaultFieldManager)
+	if r.FieldManager != "" {
+		owner = client.FieldOwner(r.FieldManager)
+	}
+	if err := r.Status().Update(ctx, &p, owner); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if cond.Status == metav1.ConditionFalse {
+		ctrl.LoggerFrom(ctx).Info("SweepPolicy is invalid, ignoring it", "reason", cond.Message)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SweepPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&sweeperv1.SweepPolicy{}).
+		Named("sweeppolicy").
+		Complete(r)
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	sweeperv1 "github.com/seekin4u/preview-sweeper/api/v1"
+)
+
+func sweepPolicyObj(name string, spec sweeperv1.SweepPolicySpec) *sweeperv1.SweepPolicy {
+	return &sweeperv1.SweepPolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+func TestCompileSweepPolicy(t *testing.T) {
+	cases := []struct {
+		name    string
+		spec    sweeperv1.SweepPolicySpec
+		wantErr string
+	}{
+		{name: "prefix", spec: sweeperv1.SweepPolicySpec{NamePrefix: "shop-pr-", TTL: "7d"}},
+		{name: "selector", spec: sweeperv1.SweepPolicySpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "shop"}}, TTL: "4h"}},
+		{name: "selects everything", spec: sweeperv1.SweepPolicySpec{Selector: &metav1.LabelSelector{}, TTL: "4h"},
+			wantErr: "selector or namePrefix must be set"},
+		{name: "bad ttl", spec: sweeperv1.SweepPolicySpec{NamePrefix: "shop-pr-", TTL: "soon"},
+			wantErr: `ttl "soon": must be a positive duration`},
+		{name: "bad selector", spec: sweeperv1.SweepPolicySpec{NamePrefix: "shop-pr-", TTL: "4h",
+			Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Near"}}}},
+			wantErr: "selector:"},
+		{name: "bad hold key", spec: sweeperv1.SweepPolicySpec{NamePrefix: "shop-pr-", TTL: "4h", HoldAnnotation: "not a key"},
+			wantErr: `holdAnnotation "not a key"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			_, err := compileSweepPolicy(sweepPolicyObj(tc.name, tc.spec))
+			if tc.wantErr == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+			}
+		})
+	}
+}
+
+func TestSweepPolicies(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	shopNS := func(ns *corev1.Namespace) *corev1.Namespace {
+		ns.Labels = map[string]string{"team": "shop"}
+		return ns
+	}
+	c := newFakeClient(nil,
+		sweepPolicyObj("shop", sweeperv1.SweepPolicySpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "shop"}},
+			TTL:      "2h", HoldAnnotation: "shop.example.com/keep", IgnoreAnnotation: "shop.example.com/pinned"}),
+		sweepPolicyObj("shop-demo", sweeperv1.SweepPolicySpec{NamePrefix: "shop-demo-", TTL: "1w", DryRun: true}),
+		sweepPolicyObj("broken", sweeperv1.SweepPolicySpec{NamePrefix: "shop-", TTL: "never"}),
+		// No enable label: the shop policy selects them by team
+		shopNS(previewNS("shop-pr-1", 3*time.Hour, nil)),
+		shopNS(previewNS("shop-pr-2", time.Hour, nil)),
+		shopNS(previewNS("shop-pr-3", 3*time.Hour, map[string]string{"shop.example.com/keep": "true"})),
+		shopNS(previewNS("shop-pr-4", 3*time.Hour, map[string]string{"shop.example.com/pinned": "true"})),
+		shopNS(previewNS("shop-pr-5", 3*time.Hour, map[string]string{AnnotationTTL: "4h"})),
+		// The longer prefix wins over the selector, and is dry-run
+		shopNS(previewNS("shop-demo-1", 8*24*time.Hour, nil)),
+		// Matches no policy, even though the flag configuration would delete it
+		previewNS("preview-old", 100*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, SweepPolicies: true}
+	s.SweepOnce(ctx)
+
+	for name, deleted := range map[string]bool{
+		"shop-pr-1": true, "shop-pr-2": false, "shop-pr-3": false, "shop-pr-4": false, "shop-pr-5": false,
+		"shop-demo-1": false, "preview-old": false,
+	} {
+		g.Expect(isDeleted(ctx, c, name)).To(Equal(deleted), name)
+	}
+
+	report, _ := s.evaluate(ctx, c)
+	reasons := map[string]string{}
+	for _, e := range report.Namespaces {
+		reasons[e.Namespace] = e.Reason
+		if e.Namespace == "shop-pr-2" {
+			g.Expect(e.TTLSource).To(Equal("sweeppolicy:shop"))
+		}
+	}
+	g.Expect(reasons).To(HaveKeyWithValue("shop-pr-3", "shop.example.com/keep"))
+	g.Expect(reasons).To(HaveKeyWithValue("shop-pr-4", "ignored (shop.example.com/pinned)"))
+	g.Expect(reasons).To(HaveKeyWithValue("preview-old", "matches no SweepPolicy"))
+}
+
+func TestSweepPolicyTTLSourceMetric(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		sweepPolicyObj("shop", sweeperv1.SweepPolicySpec{NamePrefix: "shop-pr-", TTL: "2h"}),
+		previewNS("shop-pr-1", time.Hour, nil),
+		previewNS("shop-pr-2", time.Hour, nil),
+		previewNS("shop-pr-3", time.Hour, map[string]string{AnnotationTTL: "4h"}),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, SweepPolicies: true}
+	s.SweepOnce(ctx)
+
+	// The policy TTL is not --ttl, so a change of the default doesn't affect them
+	g.Expect(testutil.ToFloat64(candidatesByTTLSource.WithLabelValues("sweeppolicy"))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(candidatesByTTLSource.WithLabelValues("annotation"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(candidatesByTTLSource.WithLabelValues("default"))).To(BeZero())
+}
+
+func TestSweepPoliciesFallBackToFlags(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// Without the CRD the flag configuration applies
+	noCRD := interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*sweeperv1.SweepPolicyList); ok {
+				return &meta.NoKindMatchError{GroupKind: sweeperv1.GroupVersion.WithKind("SweepPolicy").GroupKind()}
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}
+	c := newFakeClient(&noCRD, previewNS("preview-old", 2*time.Hour, nil))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, SweepPolicies: true}
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+
+	// Nor without valid policies
+	c = newFakeClient(nil,
+		sweepPolicyObj("broken", sweeperv1.SweepPolicySpec{NamePrefix: "preview-", TTL: "0s"}),
+		previewNS("preview-old", 2*time.Hour, nil))
+	s = &NamespaceSweeper{Client: c, TTL: time.Hour, SweepPolicies: true}
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+}
+
+func TestSweepPolicyReconciler(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	good := sweepPolicyObj("good", sweeperv1.SweepPolicySpec{NamePrefix: "shop-pr-", TTL: "2h"})
+	bad := sweepPolicyObj("bad", sweeperv1.SweepPolicySpec{NamePrefix: "shop-pr-", TTL: "2 hours"})
+	var managers []string
+	c := fake.NewClientBuilder().WithScheme(newScheme()).
+		WithObjects(good, bad).WithStatusSubresource(&sweeperv1.SweepPolicy{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, sub string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				uo := &client.SubResourceUpdateOptions{}
+				uo.ApplyOptions(opts)
+				managers = append(managers, uo.FieldManager)
+				return c.SubResource(sub).Update(ctx, obj, opts...)
+			},
+		}).Build()
+	r := &SweepPolicyReconciler{Client: c, FieldManager: "sweeper-canary"}
+
+	for _, name := range []string{"good", "bad", "missing"} {
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	var p sweeperv1.SweepPolicy
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "good"}, &p)).To(Succeed())
+	g.Expect(meta.IsStatusConditionTrue(p.Status.Conditions, ConditionValid)).To(BeTrue())
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "bad"}, &p)).To(Succeed())
+	cond := meta.FindStatusCondition(p.Status.Conditions, ConditionValid)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(cond.Message).To(ContainSubstring(`ttl "2 hours"`))
+	g.Expect(managers).To(Equal([]string{"sweeper-canary", "sweeper-canary"}))
+}
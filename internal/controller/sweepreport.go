@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var sweepReportsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "sweep_reports_total",
+	Help: "Total sweep reports by result.",
+}, []string{"result"}) // result=sent|error|dropped
+
+func init() {
+	registerMetrics(sweepReportsTotal)
+}
+
+// SweepReport is the JSON body POSTed to the sweep report webhook after every sweep.
+type SweepReport struct {
+	Controller string    `json:"controller,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	Duration   string    `json:"duration"`
+	DryRun     bool      `json:"dryRun"`
+	// Blocked is why nothing was deleted this sweep, e.g. the kill switch; empty if none.
+	Blocked    string `json:"blocked,omitempty"`
+	Scanned    int    `json:"scanned"`
+	Candidates int    `json:"candidates"`
+	Expired    int    `json:"expired"`
+	Deleted    int    `json:"deleted"`
+	// DeletedNamespaces carries labels so reports can be grouped, e.g. by team.
+	DeletedNamespaces []ReportedNamespace `json:"deletedNamespaces"`
+}
+
+// ReportedNamespace is a namespace deleted by the reported sweep.
+type ReportedNamespace struct {
+	Name      string            `json:"name"`
+	Age       string            `json:"age"`
+	TTL       string            `json:"ttl"`
+	TTLSource string            `json:"ttlSource"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// SweepReporter POSTs a SweepReport after each sweep, in the background and best-effort: a
+// report is not retried, and one that finds the previous report still in flight is dropped, so
+// a slow endpoint never delays or piles up behind sweeps.
+type SweepReporter struct {
+	URL string
+	// Timeout bounds each POST; 0 means 10s.
+	Timeout time.Duration
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	inFlight atomic.Bool
+}
+
+// Report sends r in the background. Failures are logged to logger and counted.
+func (sr *SweepReporter) Report(logger logr.Logger, r SweepReport) {
+	if !sr.inFlight.CompareAndSwap(false, true) {
+		sweepReportsTotal.WithLabelValues("dropped").Inc()
+		// Not the URL, which may carry a token
+		logger.Info("Dropping sweep report, the previous one is still being sent")
+		return
+	}
+	go func() {
+		defer sr.inFlight.Store(false)
+		if err := sr.post(r); err != nil {
+			sweepReportsTotal.WithLabelValues("error").Inc()
+			logger.Error(err, "Failed to send sweep report")
+			return
+		}
+		sweepReportsTotal.WithLabelValues("sent").Inc()
+	}()
+}
+
+func (sr *SweepReporter) post(r SweepReport) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encoding sweep report: %w", err)
+	}
+	timeout := sr.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	// Not tied to the sweep's context, which may end right after the sweep
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sr.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("sweep report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c := sr.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if uerr := (*url.Error)(nil); errors.As(err, &uerr) {
+		// The error would quote the URL, which may carry a token
+		return fmt.Errorf("sweep report request: %s: %w", uerr.Op, uerr.Err)
+	}
+	if err != nil {
+		return fmt.Errorf("sweep report request: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sweep report request: unexpected status %s", resp.Status)
+	}
+	return nil
+}
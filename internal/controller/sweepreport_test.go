@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSweepReport(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	reports := make(chan SweepReport, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rep SweepReport
+		if err := json.NewDecoder(r.Body).Decode(&rep); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reports <- rep
+	}))
+	defer srv.Close()
+
+	old := previewNS("preview-old", 2*time.Hour, nil)
+	old.Labels["team"] = "web"
+	c := newFakeClient(nil, old, previewNS("preview-new", 10*time.Minute, nil))
+	sent := testutil.ToFloat64(sweepReportsTotal.WithLabelValues("sent"))
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, ControllerID: "sweeper-0", Reporter: &SweepReporter{URL: srv.URL}}
+	s.SweepOnce(ctx)
+
+	var rep SweepReport
+	g.Eventually(reports).Should(Receive(&rep))
+	g.Expect(rep.Controller).To(Equal("sweeper-0"))
+	g.Expect(rep.Scanned).To(Equal(2))
+	g.Expect(rep.Candidates).To(Equal(2))
+	g.Expect(rep.Expired).To(Equal(1))
+	g.Expect(rep.Deleted).To(Equal(1))
+	g.Expect(rep.DeletedNamespaces).To(HaveLen(1))
+	g.Expect(rep.DeletedNamespaces[0].Name).To(Equal("preview-old"))
+	g.Expect(rep.DeletedNamespaces[0].TTL).To(Equal("1h0m0s"))
+	g.Expect(rep.DeletedNamespaces[0].Labels).To(HaveKeyWithValue("team", "web"))
+	g.Eventually(func() float64 { return testutil.ToFloat64(sweepReportsTotal.WithLabelValues("sent")) }).Should(Equal(sent + 1))
+}
+
+func TestSweepReportIsBestEffort(t *testing.T) {
+	g := NewWithT(t)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	errs := testutil.ToFloat64(sweepReportsTotal.WithLabelValues("error"))
+	dropped := testutil.ToFloat64(sweepReportsTotal.WithLabelValues("dropped"))
+	sr := &SweepReporter{URL: srv.URL}
+	start := time.Now()
+	sr.Report(logr.Discard(), SweepReport{})
+	// The first one is still in flight
+	sr.Report(logr.Discard(), SweepReport{})
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	g.Expect(testutil.ToFloat64(sweepReportsTotal.WithLabelValues("dropped"))).To(Equal(dropped + 1))
+
+	close(release)
+	g.Eventually(func() float64 { return testutil.ToFloat64(sweepReportsTotal.WithLabelValues("error")) }).Should(Equal(errs + 1))
+}
+
+func TestSweepReportKeepsURLOutOfLogs(t *testing.T) {
+	g := NewWithT(t)
+
+	var mu sync.Mutex
+	var out strings.Builder
+	logger := funcr.New(func(prefix, args string) {
+		mu.Lock()
+		defer mu.Unlock()
+		out.WriteString(args + "\n")
+	}, funcr.Options{})
+
+	// Dropped while the first one hangs
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer srv.Close()
+	sr := &SweepReporter{URL: srv.URL + "/hook?token=s3cret"}
+	sr.Report(logger, SweepReport{})
+	sr.Report(logger, SweepReport{})
+	close(release)
+
+	// And failing to connect
+	gone := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	gone.Close()
+	errs := testutil.ToFloat64(sweepReportsTotal.WithLabelValues("error"))
+	(&SweepReporter{URL: gone.URL + "/hook?token=s3cret"}).Report(logger, SweepReport{})
+	g.Eventually(func() float64 { return testutil.ToFloat64(sweepReportsTotal.WithLabelValues("error")) }).Should(Equal(errs + 1))
+
+	mu.Lock()
+	defer mu.Unlock()
+	g.Expect(out.String()).To(ContainSubstring("Dropping sweep report"))
+	g.Expect(out.String()).To(ContainSubstring("Failed to send sweep report"))
+	g.Expect(out.String()).NotTo(ContainSubstring("s3cret"))
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"regexp"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// BuiltinSystemNamespaces are never swept, whatever else is configured.
+var BuiltinSystemNamespaces = []string{"kube-system", "kube-public", "default"}
+
+// SystemNamespaceConfig adds platform namespaces to BuiltinSystemNamespaces.
+type SystemNamespaceConfig struct {
+	// Names are matched exactly.
+	Names []string
+	// Patterns match anywhere in a name unless anchored, like regexp.MatchString.
+	Patterns []*regexp.Regexp
+}
+
+// isSystemNamespace reports whether the namespace called name belongs to the platform, and why.
+// It only looks at the name, so every code path skipping system namespaces can share it, whatever
+// kind of object it handles.
+func isSystemNamespace(name string, cfg SystemNamespaceConfig) (string, bool) {
+	switch {
+	case slices.Contains(BuiltinSystemNamespaces, name):
+		return "built-in system namespace", true
+	case slices.Contains(cfg.Names, name):
+		return "configured system namespace", true
+	}
+	for _, re := range cfg.Patterns {
+		if re.MatchString(name) {
+			return "system namespace pattern " + re.String(), true
+		}
+	}
+	return "", false
+}
+
+// systemNamespace is isSystemNamespace with SystemNamespaces, plus the label markers when
+// DetectSystemNamespaces is set. SweepOnce and shadowWouldDelete must both skip through it.
+func (s *NamespaceSweeper) systemNamespace(ns *corev1.Namespace) (string, bool) {
+	if reason, ok := isSystemNamespace(ns.Name, s.SystemNamespaces); ok {
+		return reason, true
+	}
+	if s.DetectSystemNamespaces {
+		if marker, ok := systemMarker(ns, s.SystemLabelMarkers); ok {
+			return "system namespace marker " + marker, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsSystemNamespace(t *testing.T) {
+	cfg := SystemNamespaceConfig{
+		Names:    []string{"preview-shared", "platform"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`^preview-infra-`), regexp.MustCompile(`-system$`)},
+	}
+	cases := []struct {
+		name       string
+		cfg        SystemNamespaceConfig
+		want       bool
+		wantReason string
+	}{
+		{"kube-system", SystemNamespaceConfig{}, true, "built-in system namespace"},
+		{"kube-public", SystemNamespaceConfig{}, true, "built-in system namespace"},
+		{"default", SystemNamespaceConfig{}, true, "built-in system namespace"},
+		{"preview-a", SystemNamespaceConfig{}, false, ""},
+		{"", SystemNamespaceConfig{}, false, ""},
+		{"kube-system-2", SystemNamespaceConfig{}, false, ""},
+		{"Default", SystemNamespaceConfig{}, false, ""},
+		{"kube-system", cfg, true, "built-in system namespace"},
+		{"preview-shared", cfg, true, "configured system namespace"},
+		{"platform", cfg, true, "configured system namespace"},
+		{"preview-shared-2", cfg, false, ""},
+		{"preview-infra-db", cfg, true, "system namespace pattern ^preview-infra-"},
+		{"preview-pr-1-infra-db", cfg, false, ""},
+		{"preview-cert-manager-system", cfg, true, "system namespace pattern -system$"},
+		{"preview-systematic", cfg, false, ""},
+		{"preview-a", cfg, false, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			reason, got := isSystemNamespace(tc.name, tc.cfg)
+			g.Expect(got).To(Equal(tc.want))
+			g.Expect(reason).To(Equal(tc.wantReason))
+		})
+	}
+}
+
+func TestSystemNamespaceCombinesNamesAndMarkers(t *testing.T) {
+	g := NewWithT(t)
+	marked := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "preview-a", Labels: map[string]string{"openshift.io/run-level": "0"}}}
+
+	s := &NamespaceSweeper{}
+	_, ok := s.systemNamespace(marked)
+	g.Expect(ok).To(BeFalse(), "markers only count with DetectSystemNamespaces")
+
+	s.DetectSystemNamespaces = true
+	reason, ok := s.systemNamespace(marked)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(reason).To(Equal("system namespace marker openshift.io/run-level"))
+
+	// A name match wins over markers
+	s.SystemNamespaces.Names = []string{"preview-a"}
+	reason, _ = s.systemNamespace(marked)
+	g.Expect(reason).To(Equal("configured system namespace"))
+}
+
+func TestConfiguredSystemNamespacesAreNeverSwept(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-shared", 2*time.Hour, nil),
+		previewNS("preview-infra-db", 2*time.Hour, nil),
+		previewNS("preview-pr-1", 2*time.Hour, nil),
+	)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, SystemNamespaces: SystemNamespaceConfig{
+		Names:    []string{"preview-shared"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`^preview-infra-`)},
+	}}
+	res := s.SweepOnce(ctx)
+
+	g.Expect(isDeleted(ctx, c, "preview-shared")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-infra-db")).To(BeFalse())
+	g.Expect(isDeleted(ctx, c, "preview-pr-1")).To(BeTrue())
+	g.Expect(res.Candidates).To(Equal(1))
+
+	// The shadow evaluation skips them the same way
+	c = newFakeClient(nil, previewNS("preview-shared", 2*time.Hour, nil), previewNS("preview-infra-db", 2*time.Hour, nil))
+	s.Client, s.Prefixes, s.ShadowSelector = c, []string{"pr-"}, regexp.MustCompile(`^preview-`)
+	s.SweepOnce(ctx)
+	g.Expect(testutil.ToFloat64(shadowDifference.WithLabelValues("would_additionally_delete"))).To(BeZero())
+}
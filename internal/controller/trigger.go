@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// TriggerHandler runs a sweep on POST and answers with its SweepResult as JSON. The sweep waits
+// for one already running, as it shares SweepOnce's lock, and is not cut short when the client
+// goes away. Replicas that aren't the leader answer 409 Conflict.
+func (s *NamespaceSweeper) TriggerHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "use POST to trigger a sweep", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.IsLeader != nil && !s.IsLeader() {
+			http.Error(w, "not the leader, trigger the sweep on the leader replica", http.StatusConflict)
+			return
+		}
+
+		res := s.SweepOnce(context.WithoutCancel(r.Context()))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(res)
+	})
+}
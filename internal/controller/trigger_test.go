@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTriggerHandler(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	c := newFakeClient(nil,
+		previewNS("preview-old", 2*time.Hour, nil),
+		previewNS("preview-new", 10*time.Minute, nil),
+	)
+	leader := false
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, IsLeader: func() bool { return leader }}
+
+	rec := httptest.NewRecorder()
+	s.TriggerHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sweep", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+	g.Expect(rec.Header().Get("Allow")).To(Equal(http.MethodPost))
+
+	rec = httptest.NewRecorder()
+	s.TriggerHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sweep", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusConflict))
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeFalse())
+
+	leader = true
+	rec = httptest.NewRecorder()
+	s.TriggerHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/sweep", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+	var res SweepResult
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &res)).To(Succeed())
+	g.Expect(res).To(Equal(SweepResult{Scanned: 2, Candidates: 2, Expired: 1, Deleted: 1}))
+	g.Expect(isDeleted(ctx, c, "preview-old")).To(BeTrue())
+	g.Expect(isDeleted(ctx, c, "preview-new")).To(BeFalse())
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// resolvedTTL is a resolveTTL result, valid for as long as the annotations it was resolved from
+// and the default TTL are. It isn't keyed on the resourceVersion: the sweeper's own writes, e.g.
+// the sweep-count annotation, bump that every sweep without touching the TTL.
+type resolvedTTL struct {
+	ttlAnnotation    string
+	policyAnnotation string
+	defaultTTL       time.Duration
+	ttl              time.Duration
+	source           string
+	// sweep is the ttlCacheGen of the last sweep that saw the namespace
+	sweep uint64
+}
+
+// cachedResolveTTL is s.resolveTTL, reusing the previous sweep's answer for a namespace whose TTL
+// annotations haven't changed since. Entries are updated in place; pruneTTLCache drops the ones the sweep
+// didn't touch, so the cache never outgrows the List.
+func (s *NamespaceSweeper) cachedResolveTTL(ns *corev1.Namespace) (time.Duration, string) {
+	if ns.UID == "" {
+		return s.resolveTTL(ns.Annotations)
+	}
+	r, ok := s.ttlCache[ns.UID]
+	if !ok {
+		if s.ttlCache == nil {
+			s.ttlCache = map[types.UID]*resolvedTTL{}
+		}
+		r = &resolvedTTL{}
+		s.ttlCache[ns.UID] = r
+	}
+	ttlRaw, policyRaw := ns.Annotations[AnnotationTTL], ns.Annotations[AnnotationPolicy]
+	if !ok || r.ttlAnnotation != ttlRaw || r.policyAnnotation != policyRaw || r.defaultTTL != s.TTL {
+		r.ttl, r.source = s.resolveTTL(ns.Annotations)
+		r.ttlAnnotation, r.policyAnnotation, r.defaultTTL = ttlRaw, policyRaw, s.TTL
+	}
+	r.sweep = s.ttlCacheGen
+	return r.ttl, r.source
+}
+
+// pruneTTLCache forgets namespaces the current sweep didn't resolve and starts the next generation.
+func (s *NamespaceSweeper) pruneTTLCache() {
+	for uid, r := range s.ttlCache {
+		if r.sweep != s.ttlCacheGen {
+			delete(s.ttlCache, uid)
+		}
+	}
+	s.ttlCacheGen++
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestTTLCacheInvalidatedByChange(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	a := previewNS("preview-a", 90*time.Minute, map[string]string{AnnotationTTL: "2h"})
+	b := previewNS("preview-b", 10*time.Minute, nil)
+	a.UID, b.UID = "uid-a", "uid-b"
+	c := newFakeClient(nil, a, b)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour}
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-a")).To(BeFalse())
+	g.Expect(s.ttlCache).To(HaveLen(2))
+
+	var ns corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-a"}, &ns)).To(Succeed())
+	cached := *s.ttlCache[ns.UID]
+	g.Expect(cached.ttl).To(Equal(2 * time.Hour))
+
+	// A changed annotation must not reuse the cached 2h
+	g.Expect(cached.ttlAnnotation).To(Equal("2h"))
+	ns.Annotations[AnnotationTTL] = "1h"
+	g.Expect(c.Update(ctx, &ns)).To(Succeed())
+	s.SweepOnce(ctx)
+	g.Expect(isDeleted(ctx, c, "preview-a")).To(BeTrue())
+
+	// Namespaces that are gone fall out of the cache
+	s.SweepOnce(ctx)
+	g.Expect(s.ttlCache).To(HaveLen(1))
+	g.Expect(s.ttlCache).To(HaveKey(types.UID("uid-b")))
+}
+
+func TestTTLCacheSurvivesSweepCount(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	ns := previewNS("preview-a", time.Minute, map[string]string{AnnotationTTL: "2h"})
+	ns.UID = "uid-a"
+	c := newFakeClient(nil, ns)
+	s := &NamespaceSweeper{Client: c, TTL: time.Hour, TrackSweepCount: true}
+	s.SweepOnce(ctx)
+
+	// Poison the entry: only a cache hit keeps it
+	s.ttlCache["uid-a"].ttl = 5 * time.Hour
+	s.SweepOnce(ctx)
+
+	var got corev1.Namespace
+	g.Expect(c.Get(ctx, client.ObjectKey{Name: "preview-a"}, &got)).To(Succeed())
+	g.Expect(got.Annotations[AnnotationSweepCount]).To(Equal("2"), "the sweeper's own writes bump the resourceVersion")
+	g.Expect(s.ttlCache["uid-a"].ttl).To(Equal(5*time.Hour), "yet the cached TTL is reused")
+}
+
+func TestTTLCacheFollowsDefaultTTL(t *testing.T) {
+	g := NewWithT(t)
+
+	ns := previewNS("preview-a", time.Minute, nil)
+	ns.UID, ns.ResourceVersion = types.UID("uid-a"), "7"
+	s := &NamespaceSweeper{TTL: time.Hour}
+	ttl, _ := s.cachedResolveTTL(ns)
+	g.Expect(ttl).To(Equal(time.Hour))
+
+	s.TTL = 2 * time.Hour
+	ttl, src := s.cachedResolveTTL(ns)
+	g.Expect(ttl).To(Equal(2 * time.Hour))
+	g.Expect(src).To(Equal("default"))
+}
+
+func BenchmarkResolveTTL(b *testing.B) {
+	nss := make([]*corev1.Namespace, 2000)
+	for i := range nss {
+		nss[i] = previewNS(fmt.Sprintf("preview-%d", i), time.Minute, map[string]string{AnnotationTTL: " 36h30m "})
+		nss[i].UID, nss[i].ResourceVersion = types.UID(fmt.Sprint(i)), "1"
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			for _, ns := range nss {
+				resolveTTL(ns.Annotations, time.Hour)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		s := &NamespaceSweeper{TTL: time.Hour}
+		b.ReportAllocs()
+		for b.Loop() {
+			for _, ns := range nss {
+				s.cachedResolveTTL(ns)
+			}
+			s.pruneTTLCache()
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// TTLWebhookPath is where the ttl annotation webhook is served.
+const TTLWebhookPath = "/validate--v1-namespace"
+
+// ttlFormats lists the values parseDurationAnnotation accepts, for humans.
+const ttlFormats = "a Go duration (4h, 30m, 2h45m), bare hours (69), days (7d) or weeks (2w)"
+
+// TTLValidator rejects namespaces whose ttl annotation the sweeper couldn't use, so a typo such as
+// "7days" fails at apply time instead of silently falling back to the default TTL. A blank value
+// is deliberate, see EmptyTTLNever, and allowed.
+type TTLValidator struct{}
+
+// +kubebuilder:webhook:path=/validate--v1-namespace,mutating=false,failurePolicy=ignore,sideEffects=None,groups="",resources=namespaces,verbs=create;update,versions=v1,name=vnamespace-ttl.maxsauce.com,admissionReviewVersions=v1
+
+var _ admission.CustomValidator = TTLValidator{}
+
+// SetupTTLWebhookWithManager serves TTLValidator for namespaces on the manager's webhook server.
+func SetupTTLWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		WithValidator(TTLValidator{}).
+		Complete()
+}
+
+func (TTLValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Namespace, got %T", obj)
+	}
+	return nil, validateTTLAnnotation(ns)
+}
+
+// ValidateUpdate only checks a ttl annotation the update changes, so a namespace that already
+// carries a bad value can still be updated otherwise, e.g. labelled by the sweeper or finalized.
+func (TTLValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldNS, ok := oldObj.(*corev1.Namespace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Namespace, got %T", oldObj)
+	}
+	ns, ok := newObj.(*corev1.Namespace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Namespace, got %T", newObj)
+	}
+	if oldRaw, had := oldNS.Annotations[AnnotationTTL]; had && oldRaw == ns.Annotations[AnnotationTTL] {
+		return nil, nil
+	}
+	return nil, validateTTLAnnotation(ns)
+}
+
+func (TTLValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateTTLAnnotation returns an Invalid error naming the accepted formats when ns has a ttl
+// annotation that is neither blank nor a positive duration.
+func validateTTLAnnotation(ns *corev1.Namespace) error {
+	raw, ok := ns.Annotations[AnnotationTTL]
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	d, ok := parseDurationAnnotation(raw)
+	if ok && d > 0 {
+		return nil
+	}
+	msg := "must be " + ttlFormats
+	if ok {
+		msg = "must be positive"
+	}
+	path := field.NewPath("metadata", "annotations").Key(AnnotationTTL)
+	return apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Namespace").GroupKind(), ns.Name,
+		field.ErrorList{field.Invalid(path, raw, msg)})
+}
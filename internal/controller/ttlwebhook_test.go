@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestTTLValidator(t *testing.T) {
+	ctx := context.Background()
+	v := TTLValidator{}
+
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     string
+	}{
+		{name: "no annotation"},
+		{name: "go duration", annotations: map[string]string{AnnotationTTL: "2h45m"}},
+		{name: "bare hours", annotations: map[string]string{AnnotationTTL: "69"}},
+		{name: "days", annotations: map[string]string{AnnotationTTL: "7d"}},
+		{name: "blank", annotations: map[string]string{AnnotationTTL: " "}},
+		{name: "typo", annotations: map[string]string{AnnotationTTL: "7days"}, wantErr: "weeks (2w)"},
+		{name: "zero", annotations: map[string]string{AnnotationTTL: "0"}, wantErr: "must be positive"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			_, err := v.ValidateCreate(ctx, previewNS("preview-x", time.Hour, tc.annotations))
+			if tc.wantErr == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			g.Expect(err).To(MatchError(ContainSubstring(tc.wantErr)))
+		})
+	}
+}
+
+func TestTTLValidatorUpdate(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	v := TTLValidator{}
+
+	bad := previewNS("preview-x", time.Hour, map[string]string{AnnotationTTL: "7days"})
+	relabelled := bad.DeepCopy()
+	relabelled.Labels[LabelDeletedBy] = "preview-sweeper"
+	_, err := v.ValidateUpdate(ctx, bad, relabelled)
+	g.Expect(err).NotTo(HaveOccurred(), "an unchanged bad value doesn't block other updates")
+
+	fixed := previewNS("preview-x", time.Hour, map[string]string{AnnotationTTL: "7d"})
+	_, err = v.ValidateUpdate(ctx, bad, fixed)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	_, err = v.ValidateUpdate(ctx, fixed, bad)
+	g.Expect(apierrors.IsInvalid(err)).To(BeTrue())
+
+	_, err = v.ValidateUpdate(ctx, previewNS("preview-x", time.Hour, nil), bad)
+	g.Expect(apierrors.IsInvalid(err)).To(BeTrue())
+}